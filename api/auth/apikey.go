@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"net/http"
+)
+
+// HeaderAPIKey carries a static API key.
+const HeaderAPIKey = "X-API-Key"
+
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+
+// APIKeyAuthenticator authenticates requests by a static key in the X-API-Key header.
+type APIKeyAuthenticator struct {
+	// keys are indexed by their hash so that lookups do not leak key prefixes through timing
+	keys map[[sha256.Size]byte]*Tenant
+}
+
+func NewAPIKeyAuthenticator(tenants []*Tenant) *APIKeyAuthenticator {
+	keys := make(map[[sha256.Size]byte]*Tenant)
+	for _, tenant := range tenants {
+		for _, key := range tenant.APIKeys {
+			keys[sha256.Sum256([]byte(key))] = tenant
+		}
+	}
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Tenant, error) {
+	key := r.Header.Get(HeaderAPIKey)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	tenant, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return tenant, nil
+}
+
+// APIKeyHeader returns the headers authenticating a request with a static API key.
+func APIKeyHeader(key string) map[string]string {
+	return map[string]string{HeaderAPIKey: key}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries none of its credentials.
+	ErrNoCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials is returned when the credentials are unknown, malformed or expired.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator identifies the tenant a request belongs to.
+type Authenticator interface {
+	// Authenticate returns the tenant of the request, ErrNoCredentials if the request
+	// carries no credentials for this method, or an error if they are invalid.
+	Authenticate(r *http.Request) (*Tenant, error)
+}
+
+// Config configures authentication of the /verify and /settle endpoints.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Maximum difference between a signed request's timestamp and the server clock
+	MaxClockSkew time.Duration `mapstructure:"maxClockSkew"`
+	JWT          JWTConfig     `mapstructure:"jwt"`
+	Tenants      []*Tenant     `mapstructure:"tenants"`
+}
+
+// DefaultMaxClockSkew is used when Config.MaxClockSkew is not set.
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// NewAuthenticators builds every authenticator enabled by config.
+// JWT bearer tokens are accepted once public keys are configured.
+func NewAuthenticators(config Config) ([]Authenticator, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	maxSkew := config.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxClockSkew
+	}
+	authenticators := []Authenticator{
+		NewAPIKeyAuthenticator(config.Tenants),
+		NewHMACAuthenticator(config.Tenants, maxSkew),
+	}
+
+	if len(config.JWT.Keys) > 0 || config.JWT.JWKSFile != "" {
+		jwtAuthenticator, err := NewJWTAuthenticator(config.JWT, config.Tenants)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+	return authenticators, nil
+}
+
+// tenantKey is the context key for storing the authenticated tenant
+var tenantKey = &struct{}{}
+
+// WithTenant returns a copy of ctx carrying the authenticated tenant.
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// GetTenant retrieves the authenticated tenant from the context
+// Returns nil if the request was not authenticated
+func GetTenant(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(tenantKey).(*Tenant)
+	return tenant
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultJWTMaxTTL is used when JWTConfig.MaxTTL is not set.
+// It matches the two minute lifetime of tokens issued for CDP API keys.
+const DefaultJWTMaxTTL = 2 * time.Minute
+
+// jwtLeeway tolerates small clock differences when checking exp and nbf.
+const jwtLeeway = 5 * time.Second
+
+// JWTConfig configures validation of bearer tokens.
+type JWTConfig struct {
+	// PEM encoded public keys indexed by key ID (kid)
+	Keys map[string]string `mapstructure:"keys"`
+	// JWKS file with additional public keys
+	JWKSFile string `mapstructure:"jwksFile"`
+	// Maximum lifetime of an accepted token, from nbf (or iat) to exp
+	MaxTTL time.Duration `mapstructure:"maxTTL"`
+}
+
+// JWTClaims are the claims of a bearer token bound to a request.
+// The layout follows the tokens used by Coinbase's hosted facilitator (CDP API keys).
+type JWTClaims struct {
+	jwt.RegisteredClaims
+	// Requests the token may be used for, as "METHOD host/path"
+	URIs []string `json:"uris,omitempty"`
+	URI  string   `json:"uri,omitempty"`
+}
+
+// boundTo reports whether the token was issued for the method and path of r.
+// Only the path suffix is compared, since the facilitator may run behind a proxy or under a path prefix.
+func (c *JWTClaims) boundTo(r *http.Request) bool {
+	uris := c.URIs
+	if c.URI != "" {
+		uris = append(uris, c.URI)
+	}
+	for _, uri := range uris {
+		method, hostPath, ok := strings.Cut(uri, " ")
+		if !ok || method != r.Method {
+			continue
+		}
+		if i := strings.Index(hostPath, "/"); i >= 0 && strings.HasSuffix(hostPath[i:], r.URL.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Authenticator = (*JWTAuthenticator)(nil)
+
+// JWTAuthenticator authenticates requests by an ES256 or EdDSA signed bearer token.
+// Tokens must be short lived and bound to the request's method and path.
+type JWTAuthenticator struct {
+	keys    map[string]crypto.PublicKey
+	tenants map[string]*Tenant // by key ID
+	maxTTL  time.Duration
+	now     func() time.Time
+}
+
+func NewJWTAuthenticator(config JWTConfig, tenants []*Tenant) (*JWTAuthenticator, error) {
+	keys := make(map[string]crypto.PublicKey)
+	if config.JWKSFile != "" {
+		jwks, err := LoadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwks: %w", err)
+		}
+		for kid, key := range jwks {
+			keys[kid] = key
+		}
+	}
+	for kid, pem := range config.Keys {
+		key, err := ParsePublicKeyPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	byKeyID := make(map[string]*Tenant)
+	for _, tenant := range tenants {
+		for _, kid := range tenant.JWTKeyIDs {
+			if _, ok := keys[kid]; !ok {
+				return nil, fmt.Errorf("no public key for key id %s of tenant %s", kid, tenant.ID)
+			}
+			byKeyID[kid] = tenant
+		}
+	}
+
+	maxTTL := config.MaxTTL
+	if maxTTL <= 0 {
+		maxTTL = DefaultJWTMaxTTL
+	}
+	return &JWTAuthenticator{
+		keys:    keys,
+		tenants: byKeyID,
+		maxTTL:  maxTTL,
+		now:     time.Now,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Tenant, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+		jwt.WithTimeFunc(a.now),
+	)
+	var kid string
+	claims := &JWTClaims{}
+	if _, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ = t.Header["kid"].(string)
+		key, ok := a.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	tenant, ok := a.tenants[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: key id %q is not assigned to a tenant", ErrInvalidCredentials, kid)
+	}
+
+	issued := claims.NotBefore
+	if issued == nil {
+		issued = claims.IssuedAt
+	}
+	if issued == nil {
+		return nil, fmt.Errorf("%w: token has neither nbf nor iat", ErrInvalidCredentials)
+	}
+	if claims.ExpiresAt.Sub(issued.Time) > a.maxTTL {
+		return nil, fmt.Errorf("%w: token lifetime exceeds %s", ErrInvalidCredentials, a.maxTTL)
+	}
+	if !claims.boundTo(r) {
+		return nil, fmt.Errorf("%w: token is not bound to %s %s", ErrInvalidCredentials, r.Method, r.URL.Path)
+	}
+	return tenant, nil
+}
+
+// NewJWT issues a bearer token for a single request to method and hostPath (e.g. "api.example.com/verify").
+// The key must be an *ecdsa.PrivateKey on P-256 (ES256) or an ed25519.PrivateKey (EdDSA).
+func NewJWT(keyID string, key crypto.Signer, method, hostPath string, ttl time.Duration, now time.Time) (string, error) {
+	var signingMethod jwt.SigningMethod
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		signingMethod = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		signingMethod = jwt.SigningMethodEdDSA
+	default:
+		return "", errors.New("unsupported signing key type")
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(signingMethod, &JWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "cdp",
+			Subject:   keyID,
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		URIs: []string{method + " " + hostPath},
+	})
+	token.Header["kid"] = keyID
+	token.Header["nonce"] = hex.EncodeToString(nonce)
+	return token.SignedString(key)
+}
+
+// JWTHeader returns the headers authenticating a request with a bearer token.
+func JWTHeader(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// Tenant is a client of the facilitator together with its credentials
+// and the payments it is allowed to verify and settle.
+type Tenant struct {
+	ID string `mapstructure:"id"`
+	// Static keys sent in the X-API-Key header
+	APIKeys []string `mapstructure:"apiKeys"`
+	// Shared secret for HMAC signed requests, the key ID is the tenant ID
+	HMACSecret string `mapstructure:"hmacSecret"`
+	// Key IDs (kid) of the public keys validating the tenant's JWT bearer tokens
+	JWTKeyIDs []string `mapstructure:"jwtKeyIds"`
+
+	// Allowed networks, assets and payTo addresses, empty allows any
+	Networks []string `mapstructure:"networks"`
+	Assets   []string `mapstructure:"assets"`
+	PayTo    []string `mapstructure:"payTo"`
+
+	// Settlements allowed per UTC day, overriding the default quota when set
+	DailySettleQuota int64 `mapstructure:"dailySettleQuota"`
+}
+
+// Allows checks that the payment requirements are within the tenant's permissions.
+func (t *Tenant) Allows(req *types.PaymentRequirements) error {
+	if !contains(t.Networks, req.Network) {
+		return fmt.Errorf("network %q is not allowed for tenant %s", req.Network, t.ID)
+	}
+	if !contains(t.Assets, req.Asset) {
+		return fmt.Errorf("asset %q is not allowed for tenant %s", req.Asset, t.ID)
+	}
+	if !contains(t.PayTo, req.PayTo) {
+		return fmt.Errorf("payTo %q is not allowed for tenant %s", req.PayTo, t.ID)
+	}
+	return nil
+}
+
+// contains reports whether value is in list, case-insensitively since addresses may be checksummed.
+// An empty list allows any value.
+func contains(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+func TestAuth(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	authenticators, err := auth.NewAuthenticators(auth.Config{
+		Enabled: true,
+		JWT: auth.JWTConfig{
+			Keys: map[string]string{
+				"ec-key": publicKeyPEM(t, ecKey.Public()),
+				"ed-key": publicKeyPEM(t, edKey.Public()),
+			},
+		},
+		Tenants: []*auth.Tenant{{
+			ID:         "merchant",
+			APIKeys:    []string{"secret-key"},
+			HMACSecret: "hmac-secret",
+			JWTKeyIDs:  []string{"ec-key", "ed-key"},
+			Networks:   []string{"base-sepolia"},
+		}},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{Authenticators: authenticators}))
+	defer srv.Close()
+
+	jwtAuthHeader := func(keyID string, key crypto.Signer) func() (map[string]map[string]string, error) {
+		header, err := client.JWTAuthHeader(keyID, key, srv.URL)
+		require.NoError(t, err)
+		return header
+	}
+	// customJWT issues the same token for both endpoints
+	customJWT := func(path string, ttl time.Duration) func() (map[string]map[string]string, error) {
+		return func() (map[string]map[string]string, error) {
+			u, _ := url.Parse(srv.URL)
+			token, err := auth.NewJWT("ec-key", ecKey, http.MethodPost, u.Host+path, ttl, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			return map[string]map[string]string{"verify": auth.JWTHeader(token), "settle": auth.JWTHeader(token)}, nil
+		}
+	}
+
+	allowed := &types.PaymentRequirements{Network: "base-sepolia"}
+	denied := &types.PaymentRequirements{Network: "base"}
+
+	for _, tc := range []struct {
+		name       string
+		authHeader func() (map[string]map[string]string, error)
+		signer     func(req *http.Request) error
+		req        *types.PaymentRequirements
+		wantStatus int
+	}{
+		{name: "no credentials", req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "unknown api key", authHeader: client.APIKeyAuthHeader("wrong"), req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "api key", authHeader: client.APIKeyAuthHeader("secret-key"), req: allowed, wantStatus: http.StatusOK},
+		{name: "api key forbidden network", authHeader: client.APIKeyAuthHeader("secret-key"), req: denied, wantStatus: http.StatusForbidden},
+		{name: "hmac", signer: client.HMACRequestSigner("merchant", "hmac-secret"), req: allowed, wantStatus: http.StatusOK},
+		{name: "hmac wrong secret", signer: client.HMACRequestSigner("merchant", "wrong"), req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "jwt es256", authHeader: jwtAuthHeader("ec-key", ecKey), req: allowed, wantStatus: http.StatusOK},
+		{name: "jwt eddsa", authHeader: jwtAuthHeader("ed-key", edKey), req: allowed, wantStatus: http.StatusOK},
+		{name: "jwt unknown key id", authHeader: jwtAuthHeader("other-key", ecKey), req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "jwt wrong path", authHeader: customJWT("/supported", time.Minute), req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "jwt lifetime too long", authHeader: customJWT("/verify", time.Hour), req: allowed, wantStatus: http.StatusUnauthorized},
+		{name: "jwt forbidden network", authHeader: jwtAuthHeader("ec-key", ecKey), req: denied, wantStatus: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := client.NewClient(srv.URL)
+			require.NoError(t, err)
+			c.CreateAuthHeader = tc.authHeader
+			c.SignRequest = tc.signer
+
+			_, verifyErr := c.Verify(t.Context(), &types.PaymentPayload{}, tc.req)
+			_, settleErr := c.Settle(t.Context(), &types.PaymentPayload{}, tc.req)
+			if tc.wantStatus == http.StatusOK {
+				require.NoError(t, verifyErr)
+				require.NoError(t, settleErr)
+			} else {
+				require.ErrorContains(t, verifyErr, fmt.Sprintf("status %d", tc.wantStatus))
+				require.ErrorContains(t, settleErr, fmt.Sprintf("status %d", tc.wantStatus))
+			}
+		})
+	}
+
+	// /supported stays public
+	res, err := http.Get(srv.URL + "/supported")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestHMACReplay(t *testing.T) {
+	authenticators, err := auth.NewAuthenticators(auth.Config{
+		Enabled: true,
+		Tenants: []*auth.Tenant{{ID: "merchant", HMACSecret: "hmac-secret"}},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{Authenticators: authenticators}))
+	defer srv.Close()
+
+	body := `{"x402Version":1,"paymentPayload":{},"paymentRequirements":{"network":"base-sepolia"}}`
+	header := auth.HMACHeader("merchant", "hmac-secret", http.MethodPost, "/verify", []byte(body), time.Now())
+	send := func(body string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/verify", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	// the signature covers the body, and is accepted once
+	require.Equal(t, http.StatusUnauthorized, send(strings.Replace(body, "base-sepolia", "base", 1)))
+	require.Equal(t, http.StatusOK, send(body))
+	require.Equal(t, http.StatusUnauthorized, send(body))
+}
+
+func TestLoadJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	ecJWK := func(kid, crv string, key *ecdsa.PublicKey) map[string]string {
+		return map[string]string{"kty": "EC", "crv": crv, "kid": kid, "x": b64(key.X.Bytes()), "y": b64(key.Y.Bytes())}
+	}
+	edJWK := map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": b64(edPublic)}
+	offCurve := ecJWK("ec", "P-256", &ecKey.PublicKey)
+	offCurve["y"] = b64(new(big.Int).Add(ecKey.Y, big.NewInt(1)).Bytes())
+
+	load := func(keys ...map[string]string) (map[string]crypto.PublicKey, error) {
+		data, err := json.Marshal(map[string]any{"keys": keys})
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return auth.LoadJWKS(path)
+	}
+
+	keys, err := load(ecJWK("ec", "P-256", &ecKey.PublicKey), edJWK)
+	require.NoError(t, err)
+	require.True(t, ecKey.PublicKey.Equal(keys["ec"]))
+	require.True(t, edPublic.Equal(keys["ed"]))
+
+	_, err = load(ecJWK("ec", "P-256", &ecKey.PublicKey), ecJWK("ec", "P-256", &p384Key.PublicKey))
+	require.ErrorContains(t, err, "duplicate kid")
+	_, err = load(offCurve)
+	require.ErrorContains(t, err, "not on curve")
+	_, err = load(ecJWK("ec", "P-384", &p384Key.PublicKey))
+	require.ErrorContains(t, err, "unsupported key type EC/P-384")
+	// coordinates of another curve are not a P-256 point
+	_, err = load(ecJWK("ec", "P-256", &p384Key.PublicKey))
+	require.ErrorContains(t, err, "not on curve")
+	_, err = load(ecJWK("", "P-256", &ecKey.PublicKey))
+	require.ErrorContains(t, err, "without kid")
+}
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
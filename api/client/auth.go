@@ -0,0 +1,84 @@
+package client
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+)
+
+// APIKeyAuthHeader returns a CreateAuthHeader hook authenticating /verify, /settle and /payments with a static API key.
+func APIKeyAuthHeader(key string) func() (map[string]map[string]string, error) {
+	return func() (map[string]map[string]string, error) {
+		return map[string]map[string]string{
+			"verify":   auth.APIKeyHeader(key),
+			"settle":   auth.APIKeyHeader(key),
+			"payments": auth.APIKeyHeader(key),
+		}, nil
+	}
+}
+
+// JWTAuthHeader returns a CreateAuthHeader hook issuing short lived bearer tokens for /verify and /settle,
+// in the format expected by Coinbase's hosted facilitator and by this facilitator's JWT authentication.
+// baseURL is the facilitator base URL the tokens are bound to, e.g. "https://api.cdp.coinbase.com/platform/v2/x402".
+// The key must be an *ecdsa.PrivateKey on P-256 or an ed25519.PrivateKey.
+func JWTAuthHeader(keyID string, key crypto.Signer, baseURL string) (func() (map[string]map[string]string, error), error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	return func() (map[string]map[string]string, error) {
+		now := time.Now()
+		headers := make(map[string]map[string]string)
+		for _, name := range []string{"verify", "settle"} {
+			hostPath := parsed.Host + path.Join("/", parsed.Path, name)
+			token, err := auth.NewJWT(keyID, key, http.MethodPost, hostPath, auth.DefaultJWTMaxTTL, now)
+			if err != nil {
+				return nil, fmt.Errorf("create %s token: %w", name, err)
+			}
+			headers[name] = auth.JWTHeader(token)
+		}
+		return headers, nil
+	}, nil
+}
+
+// HMACRequestSigner returns a SignRequest hook signing every authenticated request and its body
+// with a tenant's shared secret.
+func HMACRequestSigner(keyID, secret string) func(req *http.Request) error {
+	return func(req *http.Request) error {
+		var body []byte
+		if req.GetBody != nil {
+			reader, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			if body, err = io.ReadAll(reader); err != nil {
+				return err
+			}
+		}
+		for k, v := range auth.HMACHeader(keyID, secret, req.Method, req.URL.Path, body, time.Now()) {
+			req.Header.Set(k, v)
+		}
+		return nil
+	}
+}
+
+// JWTRequestSigner returns a SignRequest hook issuing a bearer token bound to every authenticated request.
+func JWTRequestSigner(keyID string, key crypto.Signer) func(req *http.Request) error {
+	return func(req *http.Request) error {
+		token, err := auth.NewJWT(keyID, key, req.Method, req.URL.Host+req.URL.Path, auth.DefaultJWTMaxTTL, time.Now())
+		if err != nil {
+			return err
+		}
+		for k, v := range auth.JWTHeader(token) {
+			req.Header.Set(k, v)
+		}
+		return nil
+	}
+}
@@ -8,7 +8,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
 	"github.com/rabbitprincess/x402-facilitator/types"
 )
 
@@ -16,6 +24,9 @@ type Client struct {
 	BaseURL          *url.URL
 	HTTPClient       *http.Client
 	CreateAuthHeader func() (map[string]map[string]string, error)
+	// SignRequest adds credentials bound to the method and path of each authenticated request,
+	// for requests such as GET /payments/{id} that the fixed sections of CreateAuthHeader cannot cover.
+	SignRequest func(req *http.Request) error
 }
 
 func NewClient(baseURL string) (*Client, error) {
@@ -29,47 +40,144 @@ func NewClient(baseURL string) (*Client, error) {
 	}, nil
 }
 
-// Supported fetches the list of supported schemes.
-func (c *Client) Supported(ctx context.Context) ([]types.SupportedKind, error) {
-	var result []types.SupportedKind
-	if err := c.doRequest(ctx, http.MethodGet, "/supported", nil, "", &result); err != nil {
+// Supported fetches the supported payment kinds with their accepted assets.
+func (c *Client) Supported(ctx context.Context) (*types.SupportedResponse, error) {
+	var result types.SupportedResponse
+	if err := c.doRequest(ctx, http.MethodGet, "/supported", "/supported", nil, "", &result); err != nil {
 		return nil, err
 	}
-	return result, nil
+	return &result, nil
 }
 
 func (c *Client) Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	payment := withVersion(*payload)
 	body := types.PaymentVerifyRequest{
-		X402Version:         int(types.X402VersionV1),
-		PaymentHeader:       *payload,
+		X402Version:         payment.X402Version,
+		PaymentPayload:      payment,
 		PaymentRequirements: *req,
 	}
 
 	var resp types.PaymentVerifyResponse
-	if err := c.doRequest(ctx, http.MethodPost, "/verify", body, "verify", &resp); err != nil {
+	if err := c.doRequest(ctx, http.MethodPost, "/verify", "/verify", body, "verify", &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
+// withVersion returns the payment with the protocol version set if it was made without one,
+// the caller's payment being left as it is.
+func withVersion(payment types.PaymentPayload) types.PaymentPayload {
+	if payment.X402Version == 0 {
+		payment.X402Version = int(types.X402VersionV1)
+	}
+	return payment
+}
+
 // Settle sends a payment settlement request.
 func (c *Client) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	payment := withVersion(*payload)
 	body := types.PaymentSettleRequest{
-		X402Version:         int(types.X402VersionV1),
-		PaymentHeader:       *payload,
+		X402Version:         payment.X402Version,
+		PaymentPayload:      payment,
 		PaymentRequirements: *req,
 	}
 
 	var resp types.PaymentSettleResponse
-	if err := c.doRequest(ctx, http.MethodPost, "/settle", body, "settle", &resp); err != nil {
+	if err := c.doRequest(ctx, http.MethodPost, "/settle", "/settle", body, "settle", &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) doRequest(ctx context.Context, method, path string, body any, authKey string, out any) error {
-	// Build URL
-	u := c.BaseURL.ResolveReference(&url.URL{Path: path})
+// PaymentFilter selects payments in ListPayments. Empty fields match any payment.
+type PaymentFilter struct {
+	Payer    string
+	PayTo    string
+	Network  string
+	Asset    string
+	Resource string
+	Status   types.PaymentStatus
+	From     time.Time
+	To       time.Time
+	Limit    int
+	// NextCursor of the previous page
+	Cursor string
+}
+
+func (f *PaymentFilter) query() url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("payer", f.Payer)
+	set("payTo", f.PayTo)
+	set("network", f.Network)
+	set("asset", f.Asset)
+	set("resource", f.Resource)
+	set("status", string(f.Status))
+	set("cursor", f.Cursor)
+	if !f.From.IsZero() {
+		set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		set("to", f.To.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		set("limit", strconv.Itoa(f.Limit))
+	}
+	return query
+}
+
+// ListPayments fetches a page of payments recorded in the facilitator's ledger.
+func (c *Client) ListPayments(ctx context.Context, filter *PaymentFilter) (*types.PaymentListResponse, error) {
+	path := "/payments"
+	if filter != nil {
+		if query := filter.query(); len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+	}
+
+	var resp types.PaymentListResponse
+	if err := c.doRequest(ctx, http.MethodGet, "/payments", path, nil, "payments", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPayment fetches a payment recorded in the facilitator's ledger with its status history.
+func (c *Client) GetPayment(ctx context.Context, id string) (*types.Payment, error) {
+	var resp types.Payment
+	if err := c.doRequest(ctx, http.MethodGet, "/payments/{id}", "/payments/"+url.PathEscape(id), nil, "payments", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// doRequest sends a request to path, traced under the name of its route, the template of path,
+// so that requests of every payment share the span name of their endpoint.
+func (c *Client) doRequest(ctx context.Context, method, route, path string, body any, authKey string, out any) (err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.template", route),
+		),
+	)
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	// Build URL, keeping the base path of facilitators served under a prefix
+	ref, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	u := c.BaseURL.JoinPath(ref.Path)
+	u.RawQuery = ref.RawQuery
+	span.SetAttributes(attribute.String("url.full", u.Redacted()))
 
 	// Prepare body
 	var reader io.Reader
@@ -89,6 +197,8 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, a
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	// Propagate the trace context (W3C traceparent) to the facilitator
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	if authKey != "" && c.CreateAuthHeader != nil {
 		hdrs, err := c.CreateAuthHeader()
@@ -101,6 +211,11 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, a
 			}
 		}
 	}
+	if authKey != "" && c.SignRequest != nil {
+		if err := c.SignRequest(req); err != nil {
+			return fmt.Errorf("sign request: %w", err)
+		}
+	}
 
 	// Execute
 	resp, err := c.HTTPClient.Do(req)
@@ -108,6 +223,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, a
 		return err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		data, _ := io.ReadAll(resp.Body)
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/facilitator"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/evmtest"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// the first hardhat account pays the settled payments, the second one is the fee payer of supported_response.json
+const (
+	payerKey    = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	feePayerKey = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+func readGolden(t *testing.T, name string, v any) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	if v != nil {
+		require.NoError(t, json.Unmarshal(data, v))
+	}
+	return data
+}
+
+func post(t *testing.T, url string, body []byte) []byte {
+	res, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(data))
+	return data
+}
+
+// TestConformance serves the payment of the x402 exact scheme specification for EVM in verify_request.json
+// by a facilitator on a chain standing for base-sepolia, its clock set within the payment's validity.
+func TestConformance(t *testing.T) {
+	var request map[string]json.RawMessage
+	readGolden(t, "verify_request.json", &request)
+	var expected types.PaymentPayload
+	require.NoError(t, json.Unmarshal(request["paymentPayload"], &expected))
+	var reference evm.EVMPayload
+	require.NoError(t, json.Unmarshal(expected.Payload, &reference))
+	authorization := reference.Authorization
+
+	payer, err := crypto.HexToECDSA(payerKey)
+	require.NoError(t, err)
+	feePayer, err := crypto.HexToECDSA(feePayerKey)
+	require.NoError(t, err)
+	feePayerAddress := crypto.PubkeyToAddress(feePayer.PublicKey)
+	chain := evmtest.NewChainOf(t, expected.Network, feePayerAddress)
+	chain.Mint(t, authorization.From, authorization.Value)
+	chain.Mint(t, crypto.PubkeyToAddress(payer.PublicKey), authorization.Value)
+
+	now := time.Unix(authorization.ValidAfter.Int64(), 0)
+	f, err := facilitator.NewEVMFacilitatorWithBackend(t.Context(), chain.Client, evm.NewRawPrivateSigner(crypto.FromECDSA(feePayer)), feePayerAddress,
+		facilitator.Options{Clock: func() time.Time { return now }, Registry: chain.Registry})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(f, Options{}))
+	defer srv.Close()
+
+	header, err := types.EncodePaymentHeader(&expected)
+	require.NoError(t, err)
+
+	// the payment is sent as paymentPayload, as a base64 X-PAYMENT header or, by earlier versions, as a paymentHeader object
+	paymentHeader, err := json.Marshal(header)
+	require.NoError(t, err)
+	shapes := map[string]map[string]json.RawMessage{
+		"paymentPayload":       {"paymentPayload": request["paymentPayload"]},
+		"base64 paymentHeader": {"paymentHeader": paymentHeader},
+		"paymentHeader object": {"paymentHeader": request["paymentPayload"]},
+	}
+	for name, shape := range shapes {
+		t.Run(name, func(t *testing.T) {
+			shape["x402Version"] = request["x402Version"]
+			shape["paymentRequirements"] = request["paymentRequirements"]
+			body, err := json.Marshal(shape)
+			require.NoError(t, err)
+			require.JSONEq(t, string(readGolden(t, "verify_response.json", nil)), string(post(t, srv.URL+"/verify", body)))
+		})
+	}
+
+	t.Run("invalid signature", func(t *testing.T) {
+		// the signature does not cover another value
+		body := bytes.Replace(readGolden(t, "verify_request.json", nil), []byte(`"value": "10000"`), []byte(`"value": "20000"`), 1)
+		require.JSONEq(t, string(readGolden(t, "verify_invalid_response.json", nil)), string(post(t, srv.URL+"/verify", body)))
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		for _, version := range []string{`2`, `0`} {
+			body := bytes.Replace(readGolden(t, "verify_request.json", nil), []byte(`"x402Version": 1`), []byte(`"x402Version": `+version), -1)
+			var verified types.PaymentVerifyResponse
+			require.NoError(t, json.Unmarshal(post(t, srv.URL+"/verify", body), &verified))
+			require.False(t, verified.IsValid)
+			require.Equal(t, types.ErrInvalidX402Version.Error(), verified.InvalidReason)
+		}
+
+		// the request and its payment must be of the same version
+		body := bytes.Replace(readGolden(t, "verify_request.json", nil), []byte(`"x402Version": 1`), []byte(`"x402Version": 2`), 1)
+		var settled types.PaymentSettleResponse
+		require.NoError(t, json.Unmarshal(post(t, srv.URL+"/settle", body), &settled))
+		require.False(t, settled.Success)
+		require.Equal(t, types.ErrInvalidX402Version.Error(), settled.ErrorReason)
+	})
+
+	t.Run("supported", func(t *testing.T) {
+		res, err := http.Get(srv.URL + "/supported")
+		require.NoError(t, err)
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		require.NoError(t, err)
+		require.JSONEq(t, string(readGolden(t, "supported_response.json", nil)), string(data))
+	})
+
+	t.Run("settle", func(t *testing.T) {
+		// the reference payment expired before the chain's blocks, the payer signs the same payment now
+		now = time.Now()
+		payload, err := evm.NewEVMPayload(expected.Network, "USDC", crypto.PubkeyToAddress(payer.PublicKey).Hex(),
+			authorization.To.Hex(), authorization.Value.String(), evm.NewRawPrivateSigner(crypto.FromECDSA(payer)))
+		require.NoError(t, err)
+		expected.Payload, err = json.Marshal(payload)
+		require.NoError(t, err)
+		request["paymentPayload"], err = json.Marshal(&expected)
+		require.NoError(t, err)
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		var settled types.PaymentSettleResponse
+		require.NoError(t, json.Unmarshal(post(t, srv.URL+"/settle", body), &settled))
+		require.True(t, settled.Success, settled.ErrorReason)
+		receipt := chain.Receipt(t, common.HexToHash(settled.Transaction))
+		require.Equal(t, uint64(1), receipt.Status)
+
+		// the transaction differs with the payment's nonce
+		var golden types.PaymentSettleResponse
+		readGolden(t, "settle_response.json", &golden)
+		golden.Transaction = settled.Transaction
+		require.Equal(t, &golden, &settled)
+
+		header, err := types.EncodePaymentResponseHeader(&settled)
+		require.NoError(t, err)
+		decoded, err := types.DecodePaymentResponseHeader(header)
+		require.NoError(t, err)
+		require.Equal(t, &settled, decoded)
+
+		_, err = types.DecodePaymentHeader("not base64")
+		require.Error(t, err)
+	})
+}
+
+// TestClientVersion sends payments made without a protocol version as of the first one, without changing them.
+func TestClientVersion(t *testing.T) {
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{}))
+	defer srv.Close()
+	c, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	payload := &types.PaymentPayload{Scheme: "exact", Network: "base-sepolia"}
+	verified, err := c.Verify(t.Context(), payload, &types.PaymentRequirements{})
+	require.NoError(t, err)
+	require.True(t, verified.IsValid)
+	settled, err := c.Settle(t.Context(), payload, &types.PaymentRequirements{})
+	require.NoError(t, err)
+	require.True(t, settled.Success)
+	require.Zero(t, payload.X402Version)
+}
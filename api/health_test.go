@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+type mockHealthFacilitator struct {
+	mockFacilitator
+	healthy bool
+}
+
+func (m *mockHealthFacilitator) HealthCheck(ctx context.Context) []*types.NetworkHealth {
+	return []*types.NetworkHealth{{
+		Family:  "evm",
+		Network: "base-sepolia",
+		Healthy: m.healthy,
+		Checks:  []types.HealthCheck{{Name: "rpc", Healthy: m.healthy}},
+	}}
+}
+
+func TestReadyz(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		healthy    bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "healthy", healthy: true, wantStatus: http.StatusOK, wantBody: types.HealthStatusOK},
+		{name: "unhealthy", healthy: false, wantStatus: http.StatusServiceUnavailable, wantBody: types.HealthStatusUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer(&mockHealthFacilitator{healthy: tc.healthy}, Options{})
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			require.Equal(t, tc.wantStatus, rec.Code)
+
+			var res types.HealthResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			require.Equal(t, tc.wantBody, res.Status)
+			require.Len(t, res.Networks, 1)
+		})
+	}
+
+	// Liveness never depends on upstream state
+	rec := httptest.NewRecorder()
+	NewServer(&mockHealthFacilitator{}, Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HeaderIdempotencyKey is the request header carrying a client chosen idempotency key.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderReplayed is set on responses replayed from the store.
+const HeaderReplayed = "Idempotent-Replayed"
+
+// DefaultTTL is used when Config.TTL is not set.
+const DefaultTTL = 24 * time.Hour
+
+// Config configures idempotent handling of /settle.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Time a response is kept for replay
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Response is a stored response together with the fingerprint of the request that produced it.
+type Response struct {
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Store keeps responses by idempotency key. Facilitators behind a load balancer
+// share their responses through an external store.
+type Store interface {
+	// Get returns the response stored for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*Response, error)
+	// Put stores the response for key for ttl.
+	Put(ctx context.Context, key string, res *Response, ttl time.Duration) error
+}
+
+// InFlight deduplicates concurrent requests with the same key within a process.
+type InFlight struct {
+	mu    sync.Mutex
+	calls map[string]chan struct{}
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{calls: make(map[string]chan struct{})}
+}
+
+// Acquire marks key as in flight. If another request holds it, Acquire returns false
+// and a channel closed once that request completes.
+func (f *InFlight) Acquire(key string) (<-chan struct{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if done, ok := f.calls[key]; ok {
+		return done, false
+	}
+	f.calls[key] = make(chan struct{})
+	return nil, true
+}
+
+// Release completes the request holding key, waking up the requests waiting for it.
+func (f *InFlight) Release(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if done, ok := f.calls[key]; ok {
+		close(done)
+		delete(f.calls, key)
+	}
+}
@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often expired responses are dropped.
+const pruneInterval = time.Minute
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps responses in process memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	responses map[string]*entry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type entry struct {
+	res    *Response
+	expiry time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		responses: make(map[string]*entry),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.responses[key]
+	if !ok || !s.now().Before(e.expiry) {
+		return nil, nil
+	}
+	return e.res, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, res *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	s.responses[key] = &entry{res: res, expiry: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, e := range s.responses {
+		if !now.Before(e.expiry) {
+			delete(s.responses, key)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api/idempotency"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+type countingFacilitator struct {
+	mockFacilitator
+	settles  atomic.Int32
+	fail     atomic.Bool
+	declined atomic.Bool
+}
+
+func (m *countingFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	m.settles.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	if m.fail.Load() {
+		return nil, errors.New("rpc unavailable")
+	}
+	if m.declined.Load() {
+		return &types.PaymentSettleResponse{Success: false, ErrorReason: types.ErrInvalidExactEvmPayloadAuthorizationValidAfter.Error()}, nil
+	}
+	return &types.PaymentSettleResponse{Success: true, Transaction: "0xtx"}, nil
+}
+
+func TestSettleIdempotency(t *testing.T) {
+	facilitator := &countingFacilitator{}
+	s := NewServer(facilitator, Options{Idempotency: idempotency.Config{Enabled: true}})
+
+	settle := func(body []byte, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/settle", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(idempotency.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	const payer = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	encode := func(from string, nonce any, asset string) []byte {
+		body, err := json.Marshal(map[string]any{
+			"x402Version": 1,
+			"paymentPayload": map[string]any{
+				"x402Version": 1,
+				"network":     "base-sepolia",
+				"payload":     map[string]any{"authorization": map[string]any{"from": from, "nonce": nonce}},
+			},
+			"paymentRequirements": map[string]any{"network": "base-sepolia", "asset": asset},
+		})
+		require.NoError(t, err)
+		return body
+	}
+	request := func(nonce byte) []byte {
+		return encode(payer, common.Hash{31: nonce}.Hex(), "USDC")
+	}
+
+	t.Run("concurrent duplicates settle once", func(t *testing.T) {
+		facilitator.settles.Store(0)
+		body := request(0x01)
+
+		var wg sync.WaitGroup
+		recs := make([]*httptest.ResponseRecorder, 5)
+		for i := range recs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				recs[i] = settle(body, "retry-1")
+			}()
+		}
+		wg.Wait()
+
+		require.EqualValues(t, 1, facilitator.settles.Load())
+		replayed := 0
+		for _, rec := range recs {
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.JSONEq(t, recs[0].Body.String(), rec.Body.String())
+			if rec.Header().Get(idempotency.HeaderReplayed) != "" {
+				replayed++
+			}
+		}
+		require.Equal(t, len(recs)-1, replayed)
+
+		// same key with a different request
+		require.Equal(t, http.StatusUnprocessableEntity, settle(request(0x02), "retry-1").Code)
+	})
+
+	t.Run("authorization nonce is an implicit key", func(t *testing.T) {
+		facilitator.settles.Store(0)
+		body := request(0x03)
+		require.Equal(t, http.StatusOK, settle(body, "").Code)
+		rec := settle(body, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "true", rec.Header().Get(idempotency.HeaderReplayed))
+		require.EqualValues(t, 1, facilitator.settles.Load())
+	})
+
+	t.Run("differently encoded payments share the implicit key", func(t *testing.T) {
+		facilitator.settles.Store(0)
+		nonce := common.Hash{31: 0x06}
+		require.Equal(t, http.StatusOK, settle(encode(payer, nonce.Hex(), "USDC"), "").Code)
+
+		// the payer in lower case, the nonce as a byte array and the asset as its address
+		rec := settle(encode(strings.ToLower(payer), [32]byte(nonce), "0x036cbd53842c5426634e7929541ec2318f3dcf7e"), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "true", rec.Header().Get(idempotency.HeaderReplayed))
+		require.EqualValues(t, 1, facilitator.settles.Load())
+	})
+
+	t.Run("failures are not stored", func(t *testing.T) {
+		facilitator.settles.Store(0)
+		facilitator.fail.Store(true)
+		body := request(0x04)
+		for _, key := range []string{"", "retry-2"} {
+			rec := settle(body, key)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.JSONEq(t, `{"success":false,"errorReason":"unexpected_settle_error","transaction":"","network":"base-sepolia"}`, rec.Body.String())
+		}
+
+		facilitator.fail.Store(false)
+		for _, key := range []string{"", "retry-2"} {
+			rec := settle(body, key)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Contains(t, rec.Body.String(), `"success":true`)
+		}
+		require.EqualValues(t, 4, facilitator.settles.Load())
+	})
+
+	t.Run("declined settlements are retried under the implicit key", func(t *testing.T) {
+		facilitator.settles.Store(0)
+		facilitator.declined.Store(true)
+		body := request(0x05)
+		rec := settle(body, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `"success":false`)
+
+		facilitator.declined.Store(false)
+		rec = settle(body, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get(idempotency.HeaderReplayed))
+		require.Contains(t, rec.Body.String(), `"success":true`)
+		require.EqualValues(t, 2, facilitator.settles.Load())
+	})
+}
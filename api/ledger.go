@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/middleware"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// ledgerEntry identifies the payment and request of a ledger update
+func ledgerEntry(c echo.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) ledger.Entry {
+	ctx := c.Request().Context()
+	entry := ledger.Entry{
+		Payload:      payload,
+		Requirements: req,
+		RequestID:    middleware.GetRequestID(ctx),
+	}
+	if tenant := auth.GetTenant(ctx); tenant != nil {
+		entry.TenantID = tenant.ID
+	}
+	return entry
+}
+
+// recordVerify records a verification in the ledger
+// Failures to record are logged, the payment flow is not interrupted
+func (s *server) recordVerify(c echo.Context, payload *types.PaymentPayload, req *types.PaymentRequirements, res *types.PaymentVerifyResponse, verifyErr error) {
+	if s.ledger == nil {
+		return
+	}
+	ctx := context.WithoutCancel(c.Request().Context())
+	if err := s.ledger.RecordVerify(ctx, ledgerEntry(c, payload, req), res, verifyErr); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to record verification in ledger")
+	}
+}
+
+// recordSettle records a settlement in the ledger
+func (s *server) recordSettle(c echo.Context, payload *types.PaymentPayload, req *types.PaymentRequirements, res *types.PaymentSettleResponse, settleErr error) {
+	if s.ledger == nil {
+		return
+	}
+	ctx := context.WithoutCancel(c.Request().Context())
+	if err := s.ledger.RecordSettle(ctx, ledgerEntry(c, payload, req), res, settleErr); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to record settlement in ledger")
+	}
+}
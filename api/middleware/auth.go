@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// Auth is a middleware that authenticates requests with the given authenticators
+// The first authenticator finding its credentials on the request decides the outcome
+// The authenticated tenant is added to the context, unauthenticated requests get a 401
+// Without authenticators every request is let through
+func Auth(authenticators ...auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if len(authenticators) == 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			req := c.Request()
+			for _, authenticator := range authenticators {
+				tenant, err := authenticator.Authenticate(req)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				} else if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+				}
+
+				c.SetRequest(req.WithContext(auth.WithTenant(req.Context(), tenant)))
+				return next(c)
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized, auth.ErrNoCredentials.Error())
+		}
+	}
+}
+
+// TenantAccess is a middleware that rejects payments outside the authenticated tenant's
+// allowed networks, assets and payTo addresses with a 403
+// The body is restored so that handlers can decode it again
+func TenantAccess() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			tenant := auth.GetTenant(req.Context())
+			if tenant == nil {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			var payment struct {
+				PaymentRequirements types.PaymentRequirements `json:"paymentRequirements"`
+			}
+			if err := json.Unmarshal(body, &payment); err != nil {
+				// malformed bodies are reported by the handler
+				return next(c)
+			}
+			if err := tenant.Allows(&payment.PaymentRequirements); err != nil {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+			return next(c)
+		}
+	}
+}
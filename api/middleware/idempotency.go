@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rabbitprincess/x402-facilitator/api/idempotency"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// Idempotency is a middleware that replays the first successful response to duplicate requests
+// Requests are identified by the Idempotency-Key header, scoped to the client, or else by the
+// payment's authorization nonce, so that retried settlements never submit a second transaction
+// Under the implicit key only successful settlements are replayed, as a payment declined for now,
+// such as one not valid yet, may succeed when retried, and settlements failing with an unexpected
+// error are never replayed
+// Concurrent duplicates wait for the first request to complete
+// A key reused with a different request body gets a 422
+// The implicit key is built from the decoded payment, its token resolved by registry, so that
+// differently encoded retries of the same authorization share it
+func Idempotency(store idempotency.Store, ttl time.Duration, registry *evm.Registry) echo.MiddlewareFunc {
+	inFlight := idempotency.NewInFlight()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if store == nil {
+			return next
+		}
+		return func(c echo.Context) error {
+			req := c.Request()
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			key, implicit := idempotencyKey(c, body, registry)
+			if key == "" {
+				return next(c)
+			}
+			// the payment identifies the request under the implicit key, whatever its encoding
+			sum := sha256.Sum256(body)
+			if implicit {
+				sum = sha256.Sum256([]byte(key))
+			}
+			fingerprint := hex.EncodeToString(sum[:])
+
+			ctx := req.Context()
+			for {
+				stored, err := store.Get(ctx, key)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read idempotency store")
+				}
+				if stored != nil {
+					if stored.Fingerprint != fingerprint {
+						return echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency key was already used for a different request")
+					}
+					c.Response().Header().Set(idempotency.HeaderReplayed, "true")
+					return c.Blob(stored.StatusCode, stored.ContentType, stored.Body)
+				}
+
+				done, acquired := inFlight.Acquire(key)
+				if acquired {
+					break
+				}
+				select {
+				case <-done:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			defer inFlight.Release(key)
+
+			res := c.Response()
+			recorder := &bodyRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			if err := next(c); err != nil {
+				return err
+			}
+
+			// only successful responses are replayed, failed requests may be retried
+			if res.Status < 200 || res.Status >= 300 {
+				return nil
+			}
+			if implicit && !settled(recorder.body.Bytes()) || unexpected(recorder.body.Bytes()) {
+				return nil
+			}
+			if err := store.Put(ctx, key, &idempotency.Response{
+				Fingerprint: fingerprint,
+				StatusCode:  res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			}, ttl); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("idempotency_key", key).Msg("Failed to store idempotent response")
+			}
+			return nil
+		}
+	}
+}
+
+// idempotencyKey returns the explicit key of the request scoped to its client, or the
+// implicit key of the payment's authorization or permit, or an empty string if there is neither
+func idempotencyKey(c echo.Context, body []byte, registry *evm.Registry) (key string, implicit bool) {
+	if key := c.Request().Header.Get(idempotency.HeaderIdempotencyKey); key != "" {
+		return c.Path() + ":" + clientKey(c) + ":" + key, false
+	}
+
+	// the payment is decoded from paymentPayload or a paymentHeader
+	var request types.PaymentSettleRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", false
+	}
+	var payload struct {
+		Authorization *evm.Authorization `json:"authorization"`
+		Permit        *evm.Permit        `json:"permit"`
+	}
+	if err := json.Unmarshal(request.PaymentPayload.Payload, &payload); err != nil {
+		return "", false
+	}
+	network := request.PaymentPayload.Network
+	var signer, token common.Address
+	var nonce common.Hash
+	switch {
+	case payload.Authorization != nil:
+		domain := registry.GetDomainConfig(network, request.PaymentRequirements.Asset)
+		if domain == nil {
+			return "", false
+		}
+		signer, token, nonce = payload.Authorization.From, domain.VerifyingContract, payload.Authorization.Nonce
+	case payload.Permit != nil && payload.Permit.Nonce != nil:
+		signer, token, nonce = payload.Permit.Owner, payload.Permit.Token, common.BigToHash(payload.Permit.Nonce)
+	default:
+		return "", false
+	}
+	// an authorization or permit nonce can be used once per token and signer
+	return c.Path() + ":nonce:" + network + ":" + token.Hex() + ":" + signer.Hex() + ":" + nonce.Hex(), true
+}
+
+// settled reports whether body is the response of a successful settlement
+func settled(body []byte) bool {
+	var res types.PaymentSettleResponse
+	return json.Unmarshal(body, &res) == nil && res.Success
+}
+
+// unexpected reports whether body is the response of a settlement failing with an unexpected error,
+// which a retry may not meet
+func unexpected(body []byte) bool {
+	var res types.PaymentSettleResponse
+	return json.Unmarshal(body, &res) == nil && res.ErrorReason == types.ErrUnexpectedSettleError.Error()
+}
+
+// bodyRecorder copies the response body while writing it
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
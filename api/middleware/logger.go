@@ -6,6 +6,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger returns a middleware that logs HTTP requests and responses
@@ -18,10 +19,11 @@ func Logger() echo.MiddlewareFunc {
 
 			// Enhance the request context with the logger
 			ctx := req.Context()
-			ctx = log.With().
-				Str("request_id", requestID).
-				Logger().
-				WithContext(ctx)
+			logCtx := log.With().Str("request_id", requestID)
+			if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+				logCtx = logCtx.Str("trace_id", sc.TraceID().String())
+			}
+			ctx = logCtx.Logger().WithContext(ctx)
 
 			// Update the request with the enhanced context
 			c.SetRequest(req.WithContext(ctx))
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/ratelimit"
+)
+
+// RateLimit is a middleware that limits requests per client with a token bucket
+// Clients are identified by their tenant when authenticated, otherwise by IP
+// Requests over the limit get a 429 with a Retry-After header
+func RateLimit(limiter *ratelimit.Limiter, name string, limit ratelimit.Limit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if limiter == nil || !limit.Enabled() {
+			return next
+		}
+		return func(c echo.Context) error {
+			res, err := limiter.Allow(c.Request().Context(), name+":"+clientKey(c), limit)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check rate limit")
+			}
+			if !res.Allowed {
+				return tooManyRequests(c, res.RetryAfter, "Rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+// SettleQuota is a middleware that enforces a daily quota of settlements per client
+// The tenant's own quota takes precedence over the default quota
+// Only successful settlements count, requests that are refused or fail to settle are refunded
+func SettleQuota(limiter *ratelimit.Limiter, defaultQuota int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if limiter == nil {
+			return next
+		}
+		return func(c echo.Context) error {
+			quota := defaultQuota
+			if tenant := auth.GetTenant(c.Request().Context()); tenant != nil && tenant.DailySettleQuota > 0 {
+				quota = tenant.DailySettleQuota
+			}
+
+			ctx := c.Request().Context()
+			res, err := limiter.Quota(ctx, "settle-quota:"+clientKey(c), quota)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check settle quota")
+			}
+			if !res.Allowed {
+				refund(ctx, limiter, res)
+				return tooManyRequests(c, res.RetryAfter, "Daily settle quota exceeded")
+			}
+
+			response := c.Response()
+			recorder := &bodyRecorder{ResponseWriter: response.Writer}
+			response.Writer = recorder
+			err = next(c)
+			if err != nil || response.Status < 200 || response.Status >= 300 || !settled(recorder.body.Bytes()) {
+				refund(ctx, limiter, res)
+			}
+			return err
+		}
+	}
+}
+
+// refund takes back a settlement counted against the quota, logging failures as the request is answered anyway
+func refund(ctx context.Context, limiter *ratelimit.Limiter, res ratelimit.Result) {
+	if err := limiter.Refund(context.WithoutCancel(ctx), res); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to refund settle quota")
+	}
+}
+
+// clientKey identifies the client of a request for rate limiting
+func clientKey(c echo.Context) string {
+	if tenant := auth.GetTenant(c.Request().Context()); tenant != nil {
+		return "tenant:" + tenant.ID
+	}
+	return "ip:" + c.RealIP()
+}
+
+func tooManyRequests(c echo.Context, retryAfter time.Duration, message string) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+	return echo.NewHTTPError(http.StatusTooManyRequests, message)
+}
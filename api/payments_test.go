@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+func TestPayments(t *testing.T) {
+	paymentLedger, err := ledger.Open(t.Context(), ledger.Config{Driver: "sqlite", DSN: "file:" + filepath.Join(t.TempDir(), "ledger.db")})
+	require.NoError(t, err)
+	defer paymentLedger.Close()
+
+	authenticators, err := auth.NewAuthenticators(auth.Config{
+		Enabled: true,
+		Tenants: []*auth.Tenant{
+			{ID: "merchant", APIKeys: []string{"merchant-key"}, HMACSecret: "merchant-secret"},
+			{ID: "other", APIKeys: []string{"other-key"}},
+		},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{Authenticators: authenticators, Ledger: paymentLedger}))
+	defer srv.Close()
+
+	merchant, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+	merchant.CreateAuthHeader = client.APIKeyAuthHeader("merchant-key")
+
+	requirements := &types.PaymentRequirements{Scheme: "exact", Network: "base-sepolia", PayTo: "0xMerchant", Asset: "0xusdc", MaxAmountRequired: "1000"}
+	payload := func(nonce string) *types.PaymentPayload {
+		return &types.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: json.RawMessage(`{"authorization":{"nonce":"` + nonce + `"}}`)}
+	}
+
+	// one verified and two settled payments
+	for _, nonce := range []string{"0x01", "0x02", "0x03"} {
+		_, err := merchant.Verify(t.Context(), payload(nonce), requirements)
+		require.NoError(t, err)
+		if nonce != "0x01" {
+			_, err = merchant.Settle(t.Context(), payload(nonce), requirements)
+			require.NoError(t, err)
+		}
+	}
+
+	// filters
+	list, err := merchant.ListPayments(t.Context(), &client.PaymentFilter{Status: types.PaymentStatusSettled, PayTo: "0xmerchant"})
+	require.NoError(t, err)
+	require.Len(t, list.Payments, 2)
+	for _, payment := range list.Payments {
+		require.Equal(t, "0xtx", payment.TxHash)
+		require.Equal(t, "0xpayer", payment.Payer)
+	}
+
+	// cursor pagination, newest first
+	var ids []string
+	filter := &client.PaymentFilter{Limit: 2}
+	for {
+		page, err := merchant.ListPayments(t.Context(), filter)
+		require.NoError(t, err)
+		for _, payment := range page.Payments {
+			ids = append(ids, payment.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	require.Len(t, ids, 3)
+	newest, err := ledger.PaymentID(payload("0x03"))
+	require.NoError(t, err)
+	require.Equal(t, newest, ids[0])
+
+	// single payment with its status history, through a request signed for its path
+	signed, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+	signed.SignRequest = client.HMACRequestSigner("merchant", "merchant-secret")
+	payment, err := signed.GetPayment(t.Context(), newest)
+	require.NoError(t, err)
+	require.Equal(t, types.PaymentStatusSettled, payment.Status)
+	require.Len(t, payment.Events, 2)
+
+	// tenants only see their own payments
+	other, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+	other.CreateAuthHeader = client.APIKeyAuthHeader("other-key")
+	list, err = other.ListPayments(t.Context(), nil)
+	require.NoError(t, err)
+	require.Empty(t, list.Payments)
+	_, err = other.GetPayment(t.Context(), newest)
+	require.ErrorContains(t, err, "status 404")
+}
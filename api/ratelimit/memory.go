@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often idle buckets and expired counters are dropped.
+const pruneInterval = time.Minute
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps rate limiting state in process memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]*counter
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+type counter struct {
+	count  int64
+	expiry time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		counters: make(map[string]*counter),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), last: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(limit.burst(), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, expiry time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(time.Now())
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{expiry: expiry}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+func (s *MemoryStore) Decr(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.counters[key]; ok && c.count > 0 {
+		c.count--
+	}
+	return nil
+}
+
+// prune drops buckets that have refilled completely, since they are equivalent to new ones,
+// and counters past their expiry.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= b.limit.burst() {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.expiry) {
+			delete(s.counters, key)
+		}
+	}
+}
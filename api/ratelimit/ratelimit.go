@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate requests per second holding at most Burst requests.
+// A zero Rate disables the limit.
+type Limit struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// burst returns the bucket capacity, at least one second worth of requests.
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// Config configures rate limiting of the /verify and /settle endpoints.
+type Config struct {
+	Enabled bool  `mapstructure:"enabled"`
+	Verify  Limit `mapstructure:"verify"`
+	Settle  Limit `mapstructure:"settle"`
+	// Settlements a client may request per UTC day, zero is unlimited.
+	// Tenants can override it with their own quota.
+	DailySettleQuota int64 `mapstructure:"dailySettleQuota"`
+}
+
+// Store holds rate limiting state. The in-memory store serves a single instance,
+// facilitators behind a load balancer share their state through an external store.
+type Store interface {
+	// Take removes a token from the bucket of key, returning how long to wait
+	// for the next token if the bucket is empty.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+	// Incr increments the counter of key, which is reset at expiry, and returns its new value.
+	Incr(ctx context.Context, key string, expiry time.Time) (int64, error)
+	// Decr decrements the counter of key, taking back an increment.
+	Decr(ctx context.Context, key string) error
+}
+
+// Result is the outcome of a rate limit or quota check.
+type Result struct {
+	Allowed bool
+	// Time until the request may be retried, set when not allowed
+	RetryAfter time.Duration
+
+	// quota counter the request was counted against
+	counter string
+}
+
+// Limiter applies token bucket limits and daily quotas on top of a Store.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLimiter creates a limiter backed by store, or by an in-memory store if store is nil.
+func NewLimiter(store Store) *Limiter {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+	allowed, retryAfter, err := l.store.Take(ctx, key, limit, l.now())
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Allowed: allowed, RetryAfter: retryAfter}, nil
+}
+
+// Quota counts a request against the daily quota of key, which resets at midnight UTC.
+func (l *Limiter) Quota(ctx context.Context, key string, quota int64) (Result, error) {
+	if quota <= 0 {
+		return Result{Allowed: true}, nil
+	}
+	now := l.now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	reset := day.Add(24 * time.Hour)
+
+	counter := key + ":" + day.Format(time.DateOnly)
+	count, err := l.store.Incr(ctx, counter, reset)
+	if err != nil {
+		return Result{}, err
+	}
+	if count > quota {
+		return Result{Allowed: false, RetryAfter: reset.Sub(now), counter: counter}, nil
+	}
+	return Result{Allowed: true, counter: counter}, nil
+}
+
+// Refund takes back a request counted by Quota against the quota of its day, such as a settlement that failed.
+func (l *Limiter) Refund(ctx context.Context, res Result) error {
+	if res.counter == "" {
+		return nil
+	}
+	return l.store.Decr(ctx, res.counter)
+}
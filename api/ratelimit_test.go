@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/ratelimit"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+func TestRateLimit(t *testing.T) {
+	authenticators, err := auth.NewAuthenticators(auth.Config{
+		Enabled: true,
+		Tenants: []*auth.Tenant{
+			{ID: "small", APIKeys: []string{"small-key"}},
+			{ID: "large", APIKeys: []string{"large-key"}, DailySettleQuota: 3},
+		},
+	})
+	require.NoError(t, err)
+	s := NewServer(&mockFacilitator{}, Options{
+		Authenticators: authenticators,
+		RateLimit: ratelimit.Config{
+			Enabled:          true,
+			Verify:           ratelimit.Limit{Rate: 0.01, Burst: 2},
+			Settle:           ratelimit.Limit{Rate: 100, Burst: 100},
+			DailySettleQuota: 1,
+		},
+	})
+
+	body, err := json.Marshal(&types.PaymentVerifyRequest{X402Version: 1, PaymentPayload: types.PaymentPayload{X402Version: 1}})
+	require.NoError(t, err)
+	declined, err := json.Marshal(&types.PaymentVerifyRequest{})
+	require.NoError(t, err)
+	send := func(path, apiKey string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set(auth.HeaderAPIKey, apiKey)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(path, apiKey string) *httptest.ResponseRecorder {
+		return send(path, apiKey, body)
+	}
+
+	// Verify burst is exhausted after two requests, one token refills in 100s
+	require.Equal(t, http.StatusOK, do("/verify", "small-key").Code)
+	require.Equal(t, http.StatusOK, do("/verify", "small-key").Code)
+	rec := do("/verify", "small-key")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.InDelta(t, 100, retryAfter, 1)
+
+	// Buckets are per client and per endpoint
+	require.Equal(t, http.StatusOK, do("/verify", "large-key").Code)
+
+	// Settlements that fail or are malformed do not count against the quota
+	for range 3 {
+		rec = send("/settle", "small-key", declined)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `"success":false`)
+		require.Equal(t, http.StatusBadRequest, send("/settle", "small-key", []byte("{")).Code)
+	}
+
+	// Default daily settle quota
+	require.Equal(t, http.StatusOK, do("/settle", "small-key").Code)
+	rec = do("/settle", "small-key")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+	// refused requests do not count either, the quota is still exhausted by the settlement
+	require.Equal(t, http.StatusTooManyRequests, do("/settle", "small-key").Code)
+
+	// Tenant quota overrides the default
+	for range 3 {
+		require.Equal(t, http.StatusOK, do("/settle", "large-key").Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, do("/settle", "large-key").Code)
+}
@@ -1,36 +1,80 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	_ "github.com/rabbitprincess/x402-facilitator/api/swagger"
+	"github.com/rs/zerolog/log"
 	echoSwagger "github.com/swaggo/echo-swagger"
 
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/idempotency"
 	"github.com/rabbitprincess/x402-facilitator/api/middleware"
+	"github.com/rabbitprincess/x402-facilitator/api/ratelimit"
 	"github.com/rabbitprincess/x402-facilitator/facilitator"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
 	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
 )
 
 // @title        x402 Facilitator API
 // @version      1.0
 // @description  API server for x402 payment facilitator
+//
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+//
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 JWT bearer token, "Bearer <token>"
 type server struct {
 	*echo.Echo
 	facilitator facilitator.Facilitator
+	ledger      *ledger.Ledger
+	webhooks    *webhook.Dispatcher
 }
 
 var _ http.Handler = (*server)(nil)
 
-func NewServer(facilitator facilitator.Facilitator) *server {
+// Options configures optional server behaviour. The zero value serves every request unauthenticated.
+type Options struct {
+	// Authenticators guarding /verify and /settle, none disables authentication
+	Authenticators []auth.Authenticator
+	// Per client rate limits and quotas of /verify and /settle
+	RateLimit ratelimit.Config
+	// Store sharing rate limiting state between instances, in-memory if nil
+	RateLimitStore ratelimit.Store
+	// Replay of /settle responses to retried requests
+	Idempotency idempotency.Config
+	// Store sharing responses between instances, in-memory if nil
+	IdempotencyStore idempotency.Store
+	// Ledger recording every verification and settlement, nil disables recording
+	Ledger *ledger.Ledger
+	// Dispatcher notifying resource servers of payment events, nil disables webhooks
+	Webhooks *webhook.Dispatcher
+	// Registry resolving the tokens of payments, evm.DefaultRegistry if nil
+	Registry *evm.Registry
+}
+
+func NewServer(facilitator facilitator.Facilitator, opts Options) *server {
 	s := &server{
 		Echo:        echo.New(),
 		facilitator: facilitator,
+		ledger:      opts.Ledger,
+		webhooks:    opts.Webhooks,
 	}
 
 	s.Use(middleware.RequestID())
+	s.Use(middleware.Tracing())
 	s.Use(middleware.Logger())
 	s.Use(middleware.ErrorWrapper())
 	s.Use(echomiddleware.RecoverWithConfig(echomiddleware.RecoverConfig{
@@ -38,9 +82,50 @@ func NewServer(facilitator facilitator.Facilitator) *server {
 	}))
 	s.Use(echomiddleware.CORS())
 
-	s.POST("/verify", s.Verify)
-	s.POST("/settle", s.Settle)
+	var limiter *ratelimit.Limiter
+	if opts.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(opts.RateLimitStore)
+	}
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = evm.DefaultRegistry
+	}
+	var idempotencyStore idempotency.Store
+	idempotencyTTL := opts.Idempotency.TTL
+	if opts.Idempotency.Enabled {
+		idempotencyStore = opts.IdempotencyStore
+		if idempotencyStore == nil {
+			idempotencyStore = idempotency.NewMemoryStore()
+		}
+		if idempotencyTTL <= 0 {
+			idempotencyTTL = idempotency.DefaultTTL
+		}
+	}
+
+	authenticated := s.Group("", middleware.Auth(opts.Authenticators...))
+	// payments and webhooks are scoped to tenants, without authentication anyone would see and subscribe to every payment
+	if len(opts.Authenticators) > 0 {
+		authenticated.GET("/payments", s.ListPayments)
+		authenticated.GET("/payments/:id", s.GetPayment)
+		authenticated.GET("/webhooks", s.ListWebhooks)
+		authenticated.POST("/webhooks", s.CreateWebhook)
+		authenticated.DELETE("/webhooks/:id", s.DeleteWebhook)
+		authenticated.GET("/webhooks/dead-letters", s.ListDeadLetters)
+		authenticated.POST("/webhooks/dead-letters/:id/replay", s.ReplayDeadLetter)
+	}
+
+	payments := authenticated.Group("", middleware.TenantAccess())
+	payments.POST("/verify", s.Verify, middleware.RateLimit(limiter, "verify", opts.RateLimit.Verify))
+	payments.POST("/settle", s.Settle,
+		// replays are answered before counting against limits
+		middleware.Idempotency(idempotencyStore, idempotencyTTL, registry),
+		middleware.RateLimit(limiter, "settle", opts.RateLimit.Settle),
+		middleware.SettleQuota(limiter, opts.RateLimit.DailySettleQuota),
+	)
 	s.GET("/supported", s.Supported)
+	s.GET("/healthz", s.Healthz)
+	s.GET("/readyz", s.Readyz)
 	s.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	return s
@@ -52,9 +137,16 @@ func NewServer(facilitator facilitator.Facilitator) *server {
 // @Tags         payments
 // @Accept       json
 // @Produce      json
-// @Param        body  body      types.PaymentSettleRequest  true  "Settlement request"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        Idempotency-Key  header    string                      false  "Key identifying retries of the same settlement"
+// @Param        body             body      types.PaymentSettleRequest  true   "Settlement request"
 // @Success      200   {object}  types.PaymentSettleResponse
 // @Failure      400   {object}  echo.HTTPError
+// @Failure      401   {object}  echo.HTTPError
+// @Failure      403   {object}  echo.HTTPError
+// @Failure      422   {object}  echo.HTTPError
+// @Failure      429   {object}  echo.HTTPError
 // @Failure      500   {object}  echo.HTTPError
 // @Router       /settle [post]
 func (s *server) Settle(c echo.Context) error {
@@ -65,10 +157,28 @@ func (s *server) Settle(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Received malformed settlement request")
 	}
 
-	settle, err := s.facilitator.Settle(ctx, &settleRequest.PaymentHeader, &settleRequest.PaymentRequirements)
+	var settle *types.PaymentSettleResponse
+	var err error
+	if reason := checkVersion(settleRequest.X402Version, &settleRequest.PaymentPayload); reason != nil {
+		settle = &types.PaymentSettleResponse{
+			Success:     false,
+			ErrorReason: reason.Error(),
+			Network:     settleRequest.PaymentRequirements.Network,
+		}
+	} else {
+		settle, err = s.facilitator.Settle(ctx, &settleRequest.PaymentPayload, &settleRequest.PaymentRequirements)
+	}
+	s.recordSettle(c, &settleRequest.PaymentPayload, &settleRequest.PaymentRequirements, settle, err)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		// the error stays in the logs and the ledger, it may hold rpc urls and their api keys
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to settle payment")
+		settle = &types.PaymentSettleResponse{
+			Success:     false,
+			ErrorReason: types.ErrUnexpectedSettleError.Error(),
+			Network:     settleRequest.PaymentRequirements.Network,
+		}
 	}
+	s.publishSettle(c, &settleRequest.PaymentPayload, &settleRequest.PaymentRequirements, settle)
 	return c.JSON(http.StatusOK, settle)
 }
 
@@ -78,9 +188,14 @@ func (s *server) Settle(c echo.Context) error {
 // @Tags         payments
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Param        body  body      types.PaymentVerifyRequest  true  "Payment verification request"
 // @Success      200   {object}  types.PaymentVerifyResponse
 // @Failure      400   {object}  echo.HTTPError
+// @Failure      401   {object}  echo.HTTPError
+// @Failure      403   {object}  echo.HTTPError
+// @Failure      429   {object}  echo.HTTPError
 // @Failure      500   {object}  echo.HTTPError
 // @Router       /verify [post]
 func (s *server) Verify(c echo.Context) error {
@@ -92,20 +207,261 @@ func (s *server) Verify(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Received malformed payment requirements")
 	}
 
-	verified, err := s.facilitator.Verify(ctx, &requirement.PaymentHeader, &requirement.PaymentRequirements)
+	var verified *types.PaymentVerifyResponse
+	var err error
+	if reason := checkVersion(requirement.X402Version, &requirement.PaymentPayload); reason != nil {
+		verified = &types.PaymentVerifyResponse{
+			IsValid:       false,
+			InvalidReason: reason.Error(),
+		}
+	} else {
+		verified, err = s.facilitator.Verify(ctx, &requirement.PaymentPayload, &requirement.PaymentRequirements)
+	}
+	s.recordVerify(c, &requirement.PaymentPayload, &requirement.PaymentRequirements, verified, err)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		// the error stays in the logs and the ledger, it may hold rpc urls and their api keys
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to verify payment")
+		verified = &types.PaymentVerifyResponse{
+			IsValid:       false,
+			InvalidReason: types.ErrUnexpectedVerifyError.Error(),
+		}
 	}
+	s.publishVerify(c, &requirement.PaymentPayload, &requirement.PaymentRequirements, verified)
 
 	return c.JSON(http.StatusOK, verified)
 }
 
+// checkVersion returns the reason a request's protocol version is not served, if any.
+// The request and its payment must be of the same known version, the facilitator routing the payment by its version.
+func checkVersion(version int, payload *types.PaymentPayload) error {
+	if version != payload.X402Version || !types.X402Version(version).Valid() {
+		return types.ErrInvalidX402Version
+	}
+	return nil
+}
+
+// ListPayments returns the recorded payments matching the query
+// @Summary      List payments
+// @Description  List payments recorded in the ledger, newest first. Authenticated tenants only see their own payments.
+// @Tags         ledger
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        payer     query     string  false  "Payer address"
+// @Param        payTo     query     string  false  "Recipient address"
+// @Param        network   query     string  false  "Network"
+// @Param        asset     query     string  false  "Asset address"
+// @Param        resource  query     string  false  "Resource URL"
+// @Param        status    query     string  false  "Payment status"  Enums(verified, invalid, settled, failed)
+// @Param        from      query     string  false  "Created at or after, RFC 3339"
+// @Param        to        query     string  false  "Created before, RFC 3339"
+// @Param        limit     query     int     false  "Page size"  default(50)  maximum(500)
+// @Param        cursor    query     string  false  "Cursor of the next page"
+// @Success      200       {object}  types.PaymentListResponse
+// @Failure      400       {object}  echo.HTTPError
+// @Failure      401       {object}  echo.HTTPError
+// @Failure      404       {object}  echo.HTTPError
+// @Failure      500       {object}  echo.HTTPError
+// @Router       /payments [get]
+func (s *server) ListPayments(c echo.Context) error {
+	if s.ledger == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Payment ledger is not enabled")
+	}
+
+	filter := ledger.Filter{
+		Payer:    c.QueryParam("payer"),
+		PayTo:    c.QueryParam("payTo"),
+		Network:  c.QueryParam("network"),
+		Asset:    c.QueryParam("asset"),
+		Resource: c.QueryParam("resource"),
+		Status:   types.PaymentStatus(c.QueryParam("status")),
+		Cursor:   c.QueryParam("cursor"),
+	}
+	if err := echo.QueryParamsBinder(c).
+		Time("from", &filter.From, time.RFC3339).
+		Time("to", &filter.To, time.RFC3339).
+		Int("limit", &filter.Limit).
+		BindError(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Received malformed query parameters")
+	}
+	filter.TenantID = tenantID(c)
+
+	payments, next, err := s.ledger.List(c.Request().Context(), filter)
+	if errors.Is(err, ledger.ErrInvalidCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if payments == nil {
+		payments = []*types.Payment{}
+	}
+	return c.JSON(http.StatusOK, &types.PaymentListResponse{Payments: payments, NextCursor: next})
+}
+
+// GetPayment returns a recorded payment with its status history
+// @Summary      Get payment
+// @Description  Get a payment recorded in the ledger with its status transitions
+// @Tags         ledger
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payment ID"
+// @Success      200  {object}  types.Payment
+// @Failure      401  {object}  echo.HTTPError
+// @Failure      404  {object}  echo.HTTPError
+// @Failure      500  {object}  echo.HTTPError
+// @Router       /payments/{id} [get]
+func (s *server) GetPayment(c echo.Context) error {
+	if s.ledger == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Payment ledger is not enabled")
+	}
+
+	payment, err := s.ledger.Get(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, ledger.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	// other tenants' payments are reported as missing
+	if tenant := auth.GetTenant(c.Request().Context()); tenant != nil && payment.TenantID != tenant.ID {
+		return echo.NewHTTPError(http.StatusNotFound, ledger.ErrNotFound.Error())
+	}
+	return c.JSON(http.StatusOK, payment)
+}
+
+// ListWebhooks returns the registered webhook endpoints
+// @Summary      List webhooks
+// @Description  List the webhook endpoints of the authenticated tenant, without their secrets
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Success      200  {array}   webhook.Endpoint
+// @Failure      401  {object}  echo.HTTPError
+// @Failure      404  {object}  echo.HTTPError
+// @Router       /webhooks [get]
+func (s *server) ListWebhooks(c echo.Context) error {
+	if s.webhooks == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhooks are not enabled")
+	}
+	return c.JSON(http.StatusOK, s.webhooks.Endpoints(tenantID(c)))
+}
+
+// CreateWebhook registers a webhook endpoint
+// @Summary      Create webhook
+// @Description  Register an endpoint receiving payment.verified, payment.settled and payment.failed events of payments to the given payTo addresses.
+// @Description  Deliveries are signed with the returned secret in the X-Webhook-Signature header, "t=<timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">".
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        body  body      webhook.Endpoint  true  "Webhook endpoint, id and secret are generated if empty"
+// @Success      201   {object}  webhook.Endpoint
+// @Failure      400   {object}  echo.HTTPError
+// @Failure      401   {object}  echo.HTTPError
+// @Failure      404   {object}  echo.HTTPError
+// @Failure      409   {object}  echo.HTTPError
+// @Router       /webhooks [post]
+func (s *server) CreateWebhook(c echo.Context) error {
+	if s.webhooks == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhooks are not enabled")
+	}
+
+	endpoint := &webhook.Endpoint{}
+	if err := json.NewDecoder(c.Request().Body).Decode(endpoint); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Received malformed webhook endpoint")
+	}
+	// endpoints only receive the payments of the tenant registering them
+	endpoint.TenantID = tenantID(c)
+
+	endpoint, err := s.webhooks.Register(c.Request().Context(), endpoint)
+	if errors.Is(err, webhook.ErrTooManyEndpoints) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, endpoint)
+}
+
+// DeleteWebhook removes a webhook endpoint
+// @Summary      Delete webhook
+// @Description  Remove a webhook endpoint of the authenticated tenant
+// @Tags         webhooks
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Webhook endpoint ID"
+// @Success      204
+// @Failure      401  {object}  echo.HTTPError
+// @Failure      404  {object}  echo.HTTPError
+// @Router       /webhooks/{id} [delete]
+func (s *server) DeleteWebhook(c echo.Context) error {
+	if s.webhooks == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhooks are not enabled")
+	}
+	if err := s.webhooks.Unregister(c.Request().Context(), c.Param("id"), tenantID(c)); errors.Is(err, webhook.ErrEndpointNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListDeadLetters returns the webhook deliveries that failed on every attempt
+// @Summary      List dead letters
+// @Description  List the events that could not be delivered to the authenticated tenant's endpoints
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Success      200  {array}   webhook.DeadLetter
+// @Failure      401  {object}  echo.HTTPError
+// @Failure      404  {object}  echo.HTTPError
+// @Router       /webhooks/dead-letters [get]
+func (s *server) ListDeadLetters(c echo.Context) error {
+	if s.webhooks == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhooks are not enabled")
+	}
+	return c.JSON(http.StatusOK, s.webhooks.DeadLetters(tenantID(c)))
+}
+
+// ReplayDeadLetter delivers a dead letter again
+// @Summary      Replay dead letter
+// @Description  Remove an event from the dead letters and deliver it again with a fresh set of attempts
+// @Tags         webhooks
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Dead letter ID"
+// @Success      202
+// @Failure      401  {object}  echo.HTTPError
+// @Failure      404  {object}  echo.HTTPError
+// @Router       /webhooks/dead-letters/{id}/replay [post]
+func (s *server) ReplayDeadLetter(c echo.Context) error {
+	if s.webhooks == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhooks are not enabled")
+	}
+	if err := s.webhooks.Replay(c.Request().Context(), c.Param("id"), tenantID(c)); errors.Is(err, webhook.ErrDeadLetterNotFound) || errors.Is(err, webhook.ErrEndpointNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// tenantID returns the ID of the authenticated tenant, empty if authentication is disabled
+func tenantID(c echo.Context) string {
+	if tenant := auth.GetTenant(c.Request().Context()); tenant != nil {
+		return tenant.ID
+	}
+	return ""
+}
+
 // Supported returns the list of supported payment kinds
 // @Summary      List supported kinds
-// @Description  Get supported payment kinds
+// @Description  Get supported payment kinds with their accepted assets and fee payer
 // @Tags         payments
 // @Produce      json
-// @Success      200  {array}   types.SupportedKind
+// @Success      200  {object}  types.SupportedResponse
 // @Failure      404  {object}  echo.HTTPError
 // @Router       /supported [get]
 func (s *server) Supported(c echo.Context) error {
@@ -114,5 +470,54 @@ func (s *server) Supported(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "No supported payment kinds found")
 	}
 
-	return c.JSON(http.StatusOK, kinds)
+	res := &types.SupportedResponse{Kinds: make([]types.SupportedKind, 0, len(kinds))}
+	for _, kind := range kinds {
+		res.Kinds = append(res.Kinds, *kind)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// Healthz reports whether the process is up
+// @Summary      Liveness probe
+// @Description  Report that the facilitator process is running
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  types.HealthResponse
+// @Router       /healthz [get]
+func (s *server) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, &types.HealthResponse{Status: types.HealthStatusOK})
+}
+
+// readyTimeout bounds the time spent probing upstream dependencies.
+const readyTimeout = 5 * time.Second
+
+// Readyz reports whether every configured network is ready to serve payments
+// @Summary      Readiness probe
+// @Description  Probe the RPC node, chain ID, signer and chain head of each configured network
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  types.HealthResponse
+// @Failure      503  {object}  types.HealthResponse
+// @Router       /readyz [get]
+func (s *server) Readyz(c echo.Context) error {
+	res := &types.HealthResponse{Status: types.HealthStatusOK}
+
+	checker, ok := s.facilitator.(facilitator.HealthChecker)
+	if !ok {
+		return c.JSON(http.StatusOK, res)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readyTimeout)
+	defer cancel()
+
+	res.Networks = checker.HealthCheck(ctx)
+	for _, network := range res.Networks {
+		if !network.Healthy {
+			res.Status = types.HealthStatusUnavailable
+		}
+	}
+	if res.Status != types.HealthStatusOK {
+		return c.JSON(http.StatusServiceUnavailable, res)
+	}
+	return c.JSON(http.StatusOK, res)
 }
@@ -15,8 +15,239 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/healthz": {
+            "get": {
+                "description": "Report that the facilitator process is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List payments recorded in the ledger, newest first. Authenticated tenants only see their own payments.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "List payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payer address",
+                        "name": "payer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient address",
+                        "name": "payTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Network",
+                        "name": "network",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Asset address",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource URL",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "verified",
+                            "invalid",
+                            "settled",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Payment status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PaymentListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a payment recorded in the ledger with its status transitions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledger"
+                ],
+                "summary": "Get payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.Payment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Probe the RPC node, chain ID, signer and chain head of each configured network",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/settle": {
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Settle a payment using the facilitator",
                 "consumes": [
                     "application/json"
@@ -29,6 +260,12 @@ const docTemplate = `{
                 ],
                 "summary": "Settle payment",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key identifying retries of the same settlement",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
                     {
                         "description": "Settlement request",
                         "name": "body",
@@ -52,6 +289,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -63,7 +324,7 @@ const docTemplate = `{
         },
         "/supported": {
             "get": {
-                "description": "Get supported payment kinds",
+                "description": "Get supported payment kinds with their accepted assets and fee payer",
                 "produces": [
                     "application/json"
                 ],
@@ -71,16 +332,235 @@ const docTemplate = `{
                     "payments"
                 ],
                 "summary": "List supported kinds",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.SupportedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verify a payment using the facilitator",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Verify payment",
+                "parameters": [
+                    {
+                        "description": "Payment verification request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.PaymentVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PaymentVerifyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the webhook endpoints of the authenticated tenant, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Endpoint"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register an endpoint receiving payment.verified, payment.settled and payment.failed events of payments to the given payTo addresses.\nDeliveries are signed with the returned secret in the X-Webhook-Signature header, \"t=\u003ctimestamp\u003e,v1=\u003chex HMAC-SHA256 of \"\u003ctimestamp\u003e.\u003cbody\u003e\"\u003e\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook endpoint, id and secret are generated if empty",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Endpoint"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Endpoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the events that could not be delivered to the authenticated tenant's endpoints",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List dead letters",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/types.SupportedKind"
+                                "$ref": "#/definitions/webhook.DeadLetter"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -90,58 +570,265 @@ const docTemplate = `{
                 }
             }
         },
-        "/verify": {
-            "post": {
-                "description": "Verify a payment using the facilitator",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "payments"
-                ],
-                "summary": "Verify payment",
-                "parameters": [
-                    {
-                        "description": "Payment verification request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.PaymentVerifyRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/types.PaymentVerifyResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    }
+        "/webhooks/dead-letters/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an event from the dead letters and deliver it again with a fresh set of attempts",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a webhook endpoint of the authenticated tenant",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook endpoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "echo.HTTPError": {
+            "type": "object",
+            "properties": {
+                "message": {}
+            }
+        },
+        "types.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error message or reason for failure, if applicable",
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "networks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.NetworkHealth"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.NetworkHealth": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "description": "Chain ID reported by the upstream RPC node",
+                    "type": "string"
+                },
+                "checks": {
+                    "description": "Individual probes run against the network",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.HealthCheck"
+                    }
+                },
+                "family": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "latestBlock": {
+                    "description": "Latest block number and its age in seconds",
+                    "type": "integer"
+                },
+                "latestBlockAge": {
+                    "type": "integer"
+                },
+                "network": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "asset": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error of the last failed settlement",
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Status transitions, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.PaymentEvent"
+                    }
+                },
+                "id": {
+                    "description": "Identifier derived from the payment payload",
+                    "type": "string"
+                },
+                "invalidReason": {
+                    "description": "Reason of the last failed verification",
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "payTo": {
+                    "type": "string"
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "requestId": {
+                    "description": "Request and tenant of the last update",
+                    "type": "string"
+                },
+                "requirements": {
+                    "type": "object"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "scheme": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.PaymentStatus"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "description": "Transaction hash of the settlement",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.PaymentEvent": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.PaymentStatus"
+                },
+                "txHash": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "echo.HTTPError": {
+        },
+        "types.PaymentListResponse": {
             "type": "object",
             "properties": {
-                "message": {}
+                "nextCursor": {
+                    "description": "Cursor of the next page, empty on the last page",
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Payment"
+                    }
+                }
             }
         },
         "types.PaymentPayload": {
@@ -168,9 +855,33 @@ const docTemplate = `{
                 }
             }
         },
+        "types.PaymentReceipt": {
+            "type": "object",
+            "properties": {
+                "network": {
+                    "type": "string"
+                },
+                "paidAt": {
+                    "type": "string"
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "type": "string"
+                }
+            }
+        },
         "types.PaymentRequirements": {
             "type": "object",
             "properties": {
+                "amount": {
+                    "description": "Amount to settle in atomic units under the upto scheme, at most MaxAmountRequired.\nOnly set when settling, MaxAmountRequired is settled if empty",
+                    "type": "string"
+                },
                 "asset": {
                     "description": "Address of the EIP-3009 compliant ERC20 contract",
                     "type": "string"
@@ -226,7 +937,7 @@ const docTemplate = `{
         "types.PaymentSettleRequest": {
             "type": "object",
             "properties": {
-                "paymentHeader": {
+                "paymentPayload": {
                     "$ref": "#/definitions/types.PaymentPayload"
                 },
                 "paymentRequirements": {
@@ -240,28 +951,51 @@ const docTemplate = `{
         "types.PaymentSettleResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "description": "Error message, if any",
+                "amount": {
+                    "description": "Amount transferred in atomic units",
                     "type": "string"
                 },
-                "networkId": {
-                    "description": "Network ID where the transaction was submitted",
+                "errorReason": {
+                    "description": "Reason of the failure, if any",
+                    "type": "string"
+                },
+                "network": {
+                    "description": "Network where the transaction was submitted",
+                    "type": "string"
+                },
+                "payer": {
+                    "description": "Address of the payer",
                     "type": "string"
                 },
                 "success": {
                     "description": "Whether the payment was successful",
                     "type": "boolean"
                 },
-                "txHash": {
+                "transaction": {
                     "description": "Transaction hash of the settled payment",
                     "type": "string"
                 }
             }
         },
+        "types.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "verified",
+                "invalid",
+                "settled",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "PaymentStatusVerified",
+                "PaymentStatusInvalid",
+                "PaymentStatusSettled",
+                "PaymentStatusFailed"
+            ]
+        },
         "types.PaymentVerifyRequest": {
             "type": "object",
             "properties": {
-                "paymentHeader": {
+                "paymentPayload": {
                     "$ref": "#/definitions/types.PaymentPayload"
                 },
                 "paymentRequirements": {
@@ -285,20 +1019,219 @@ const docTemplate = `{
                 },
                 "payer": {
                     "type": "string"
+                },
+                "receipt": {
+                    "description": "Previous settlement of a one-time resource, set when the payer already paid for it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.PaymentReceipt"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.SupportedAsset": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address of the token contract, the asset of payment requirements",
+                    "type": "string"
+                },
+                "decimals": {
+                    "type": "integer"
+                },
+                "minAmountRequired": {
+                    "description": "Minimum amount in atomic units a payment must be worth, covering the gas of its settlement",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "EIP-712 domain name and version of the token, the extra of payment requirements",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
                 }
             }
         },
         "types.SupportedKind": {
             "type": "object",
             "properties": {
+                "assets": {
+                    "description": "Assets accepted on the network",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SupportedAsset"
+                    }
+                },
+                "extra": {
+                    "description": "Extra information clients need to pay with the scheme, such as the spender of upto permits",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "feePayer": {
+                    "description": "Address of the facilitator paying the gas of settlements",
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "scheme": {
+                    "type": "string"
+                },
+                "x402Version": {
+                    "description": "Version of the x402 protocol the kind is served with",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.SupportedResponse": {
+            "type": "object",
+            "properties": {
+                "kinds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SupportedKind"
+                    }
+                }
+            }
+        },
+        "webhook.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "endpointId": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/webhook.Event"
+                },
+                "failedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.Endpoint": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "Subscribed event types, empty subscribes to every type",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payTo": {
+                    "description": "Recipient addresses to receive events for, empty receives every payment",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret signing the deliveries, only returned when the endpoint is created",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "Tenant owning the endpoint, which only receives the tenant's payments",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.Event": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "data": {
+                    "$ref": "#/definitions/webhook.EventData"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.EventData": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "asset": {
+                    "type": "string"
+                },
                 "network": {
                     "type": "string"
                 },
+                "payTo": {
+                    "type": "string"
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "paymentId": {
+                    "description": "Ledger identifier of the payment",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason of a failed settlement",
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
                 "scheme": {
                     "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT bearer token, \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+type mockFacilitator struct{}
+
+func (m *mockFacilitator) Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	return &types.PaymentVerifyResponse{IsValid: true, Payer: "0xpayer"}, nil
+}
+
+func (m *mockFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	return &types.PaymentSettleResponse{Success: true, Transaction: "0xtx"}, nil
+}
+
+func (m *mockFacilitator) Supported() []*types.SupportedKind {
+	return []*types.SupportedKind{{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}}
+}
+
+func TestTracingPropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := telemetry.NewTracerProvider(telemetry.Config{}, sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{}))
+	defer srv.Close()
+
+	c, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	ctx, root := provider.Tracer("test").Start(t.Context(), "root")
+	_, err = c.Verify(ctx, &types.PaymentPayload{}, &types.PaymentRequirements{})
+	require.NoError(t, err)
+	root.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3, "root, client and server spans")
+
+	var clientSpan, serverSpan tracetest.SpanStub
+	for _, span := range spans {
+		require.Equal(t, root.SpanContext().TraceID(), span.SpanContext.TraceID())
+		if span.Parent.SpanID() == root.SpanContext().SpanID() {
+			clientSpan = span
+		}
+	}
+	for _, span := range spans {
+		if span.Parent.SpanID() == clientSpan.SpanContext.SpanID() {
+			serverSpan = span
+		}
+	}
+	require.Equal(t, "POST /verify", clientSpan.Name)
+	require.Equal(t, "POST /verify", serverSpan.Name, "server span must be a child of the client span")
+
+	var requestID string
+	for _, attr := range serverSpan.Attributes {
+		if attr.Key == telemetry.AttrRequestID {
+			requestID = attr.Value.AsString()
+		}
+	}
+	require.NotEmpty(t, requestID)
+}
+
+func TestTracingRoute(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := telemetry.NewTracerProvider(telemetry.Config{}, sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{}))
+	defer srv.Close()
+
+	c, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	// payments are not served without authentication, the request is traced all the same
+	_, err = c.GetPayment(t.Context(), "payment-1")
+	require.Error(t, err)
+	_, err = c.ListPayments(t.Context(), &client.PaymentFilter{Payer: "0xpayer"})
+	require.Error(t, err)
+
+	urls := make(map[string]string)
+	for _, span := range exporter.GetSpans() {
+		for _, attr := range span.Attributes {
+			if attr.Key == "url.full" {
+				urls[span.Name] = attr.Value.AsString()
+			}
+		}
+	}
+	require.Equal(t, map[string]string{
+		"GET /payments/{id}": srv.URL + "/payments/payment-1",
+		"GET /payments":      srv.URL + "/payments?payer=0xpayer",
+	}, urls)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
+)
+
+// publish sends an event about the payment of the current request to the webhook endpoints
+func (s *server) publish(c echo.Context, eventType string, payload *types.PaymentPayload, req *types.PaymentRequirements, setData func(*webhook.EventData)) {
+	if s.webhooks == nil {
+		return
+	}
+	entry := ledgerEntry(c, payload, req)
+	paymentID, _ := ledger.PaymentID(payload)
+	data := webhook.EventData{
+		PaymentID: paymentID,
+		Scheme:    payload.Scheme,
+		Network:   payload.Network,
+		PayTo:     req.PayTo,
+		Asset:     req.Asset,
+		Amount:    req.SettleAmount(),
+		Resource:  req.Resource,
+		RequestID: entry.RequestID,
+		TenantID:  entry.TenantID,
+	}
+	setData(&data)
+	s.webhooks.Publish(webhook.NewEvent(eventType, data))
+}
+
+// publishVerify notifies successful verifications
+func (s *server) publishVerify(c echo.Context, payload *types.PaymentPayload, req *types.PaymentRequirements, res *types.PaymentVerifyResponse) {
+	if !res.IsValid {
+		return
+	}
+	s.publish(c, webhook.EventPaymentVerified, payload, req, func(data *webhook.EventData) {
+		data.Payer = res.Payer
+	})
+}
+
+// publishSettle notifies settled and failed settlements
+func (s *server) publishSettle(c echo.Context, payload *types.PaymentPayload, req *types.PaymentRequirements, res *types.PaymentSettleResponse) {
+	switch {
+	case res.Success:
+		s.publish(c, webhook.EventPaymentSettled, payload, req, func(data *webhook.EventData) {
+			data.TxHash = res.Transaction
+		})
+	default:
+		s.publish(c, webhook.EventPaymentFailed, payload, req, func(data *webhook.EventData) {
+			data.TxHash = res.Transaction
+			data.Reason = res.ErrorReason
+		})
+	}
+}
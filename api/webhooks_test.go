@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
+)
+
+func TestWebhooks(t *testing.T) {
+	dispatcher, err := webhook.NewDispatcher(t.Context(), webhook.Config{AllowPrivateNetworks: true}, nil)
+	require.NoError(t, err)
+	defer dispatcher.Close()
+
+	authenticators, err := auth.NewAuthenticators(auth.Config{
+		Enabled: true,
+		Tenants: []*auth.Tenant{{ID: "merchant", APIKeys: []string{"merchant-key"}}},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{Authenticators: authenticators, Webhooks: dispatcher}))
+	defer srv.Close()
+
+	type delivery struct {
+		signature string
+		body      []byte
+	}
+	deliveries := make(chan delivery, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{signature: r.Header.Get(webhook.HeaderSignature), body: body}
+	}))
+	defer receiver.Close()
+
+	// register an endpoint for settlements
+	body, err := json.Marshal(&webhook.Endpoint{URL: receiver.URL, Events: []string{webhook.EventPaymentSettled}})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/webhooks", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(auth.HeaderAPIKey, "merchant-key")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var endpoint webhook.Endpoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&endpoint))
+	require.Equal(t, "merchant", endpoint.TenantID)
+	require.NotEmpty(t, endpoint.Secret)
+
+	c, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+	c.CreateAuthHeader = client.APIKeyAuthHeader("merchant-key")
+	requirements := &types.PaymentRequirements{Network: "base-sepolia", PayTo: "0xmerchant"}
+	_, err = c.Verify(t.Context(), &types.PaymentPayload{}, requirements)
+	require.NoError(t, err)
+	_, err = c.Settle(t.Context(), &types.PaymentPayload{}, requirements)
+	require.NoError(t, err)
+
+	// only the subscribed settlement event is delivered, signed with the endpoint's secret
+	select {
+	case d := <-deliveries:
+		require.NoError(t, webhook.VerifySignature(endpoint.Secret, d.signature, d.body, webhook.DefaultTolerance, time.Now()))
+		var event webhook.Event
+		require.NoError(t, json.Unmarshal(d.body, &event))
+		require.Equal(t, webhook.EventPaymentSettled, event.Type)
+		require.Equal(t, "0xtx", event.Data.TxHash)
+		require.Equal(t, "merchant", event.Data.TenantID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook delivered")
+	}
+	require.Empty(t, deliveries)
+}
+
+func TestWebhooksRequireAuth(t *testing.T) {
+	dispatcher, err := webhook.NewDispatcher(t.Context(), webhook.Config{}, nil)
+	require.NoError(t, err)
+	defer dispatcher.Close()
+
+	// without authenticators tenants cannot be told apart, so the routes are not served
+	srv := httptest.NewServer(NewServer(&mockFacilitator{}, Options{Webhooks: dispatcher}))
+	defer srv.Close()
+	for _, path := range []string{"/webhooks", "/webhooks/dead-letters", "/payments"} {
+		res, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode, path)
+	}
+}
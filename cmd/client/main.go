@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 
 	"github.com/rabbitprincess/x402-facilitator/api/client"
 	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
@@ -18,26 +20,30 @@ var cmd = &cobra.Command{
 }
 
 var (
-	url     string
-	scheme  string
-	network string
-	token   string
-	from    string
-	to      string
-	amount  string
-	privkey string
+	url      string
+	scheme   string
+	network  string
+	token    string
+	from     string
+	to       string
+	amount   string
+	settle   string
+	resource string
+	privkey  string
 )
 
 func init() {
 	fs := cmd.PersistentFlags()
 
 	fs.StringVarP(&url, "url", "u", "http://localhost:9090", "Base URL of the facilitator server")
-	fs.StringVarP(&scheme, "scheme", "s", "evm", "Scheme to use")
+	fs.StringVarP(&scheme, "scheme", "s", "exact", "Payment scheme to use (exact, upto)")
 	fs.StringVarP(&network, "network", "n", "base-sepolia", "Blockchain network to use")
 	fs.StringVarP(&token, "token", "t", "USDC", "token contract for sending")
 	fs.StringVarP(&from, "from", "F", "", "Sender address")
 	fs.StringVarP(&to, "to", "T", "", "Recipient address")
-	fs.StringVarP(&amount, "amount", "A", "", "Amount to send")
+	fs.StringVarP(&amount, "amount", "A", "", "Amount to send, the maximum amount for upto")
+	fs.StringVar(&settle, "settle", "", "Amount to settle for upto, the maximum amount by default")
+	fs.StringVar(&resource, "resource", "", "URL of the resource paid for, signed in upto permits")
 	fs.StringVarP(&privkey, "privkey", "P", "", "Sender private key")
 }
 
@@ -58,12 +64,12 @@ func run(cmd *cobra.Command, args []string) {
 	log.Info().Msg("Sending payment request")
 	var paymentPayload *types.PaymentPayload
 	var paymentRequirements *types.PaymentRequirements
-	switch scheme {
-	case "evm":
-		priv, err := hex.DecodeString(privkey)
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to decode private key")
-		}
+	priv, err := hex.DecodeString(privkey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to decode private key")
+	}
+	switch types.Scheme(scheme) {
+	case types.SchemeExact:
 		evmPayload, err := evm.NewEVMPayload(network, token, from, to, amount, evm.NewRawPrivateSigner(priv))
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to create EVM payload")
@@ -84,6 +90,35 @@ func run(cmd *cobra.Command, args []string) {
 			PayTo:   to,
 			Asset:   token,
 		}
+	case types.SchemeUpto:
+		spender, err := uptoSpender(cmd.Context(), client)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get upto spender")
+		}
+		uptoPayload, err := evm.NewUptoPayload(network, token, from, spender, to, resource, amount, evm.NewRawPrivateSigner(priv))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create upto payload")
+		}
+		jsonPayload, err := json.Marshal(uptoPayload)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to marshal upto payload to JSON")
+		}
+		paymentPayload = &types.PaymentPayload{
+			X402Version: int(types.X402VersionV1),
+			Scheme:      scheme,
+			Network:     network,
+			Payload:     jsonPayload,
+		}
+		paymentRequirements = &types.PaymentRequirements{
+			Scheme:            scheme,
+			Network:           network,
+			MaxAmountRequired: amount,
+			Resource:          resource,
+			PayTo:             to,
+			Asset:             token,
+		}
+	default:
+		log.Fatal().Str("scheme", scheme).Msg("Unsupported scheme")
 	}
 
 	verifyResp, err := client.Verify(cmd.Context(), paymentPayload, paymentRequirements)
@@ -95,14 +130,35 @@ func run(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	paymentRequirements.Amount = settle
 	settleResp, err := client.Settle(cmd.Context(), paymentPayload, paymentRequirements)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to settle payment")
 	}
 	if !settleResp.Success {
-		log.Error().Msg("Payment settlement failed")
+		log.Error().Str("reason", settleResp.ErrorReason).Msg("Payment settlement failed")
 		return
 	}
-	log.Info().Msg("Payment settled successfully")
+	log.Info().Str("txHash", settleResp.Transaction).Str("amount", settleResp.Amount).Msg("Payment settled successfully")
+}
 
+// uptoSpender returns the facilitator address upto permits are granted to.
+func uptoSpender(ctx context.Context, c *client.Client) (string, error) {
+	supported, err := c.Supported(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, kind := range supported.Kinds {
+		if kind.Scheme != string(types.SchemeUpto) || kind.Network != network || kind.Extra == nil {
+			continue
+		}
+		var extra struct {
+			Spender string `json:"spender"`
+		}
+		if err := json.Unmarshal(*kind.Extra, &extra); err != nil {
+			return "", err
+		}
+		return extra.Spender, nil
+	}
+	return "", fmt.Errorf("facilitator does not support upto on %s", network)
 }
@@ -1,18 +1,66 @@
 package main
 
 import (
+	"time"
+
 	"github.com/knadh/koanf/parsers/toml"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
+	"github.com/rabbitprincess/x402-facilitator/api/idempotency"
+	"github.com/rabbitprincess/x402-facilitator/api/ratelimit"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/paid"
+	"github.com/rabbitprincess/x402-facilitator/policy"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/rpcpool"
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
 	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
 )
 
 type Config struct {
-	Scheme     types.Scheme `mapstructure:"scheme"`
+	Family types.Family `mapstructure:"family"`
+	// Deprecated: the network family used to be configured as scheme, use Family.
+	Scheme     types.Family `mapstructure:"scheme"`
 	Network    string       `mapstructure:"network"`
 	Port       int          `mapstructure:"port"`
 	Url        string       `mapstructure:"url"`
+	Urls       []string     `mapstructure:"urls"`
 	PrivateKey string       `mapstructure:"privateKey"`
+	// Minimum payment in atomic units covering the gas of its settlement
+	MinAmountRequired string `mapstructure:"minAmountRequired"`
+	// Time the on-chain EIP-712 domains of tokens are cached
+	DomainTTL time.Duration `mapstructure:"domainTtl"`
+	// Chains and tokens merged into the built-in registry by network name
+	Chains map[string]evm.ChainConfig `mapstructure:"chains"`
+
+	RPC           rpcpool.Config     `mapstructure:"rpc"`
+	Telemetry     telemetry.Config   `mapstructure:"telemetry"`
+	Auth          auth.Config        `mapstructure:"auth"`
+	RateLimit     ratelimit.Config   `mapstructure:"rateLimit"`
+	Idempotency   idempotency.Config `mapstructure:"idempotency"`
+	PaidResources paid.Config        `mapstructure:"paidResources"`
+	Policy        policy.Config      `mapstructure:"policy"`
+	Ledger        ledger.Config      `mapstructure:"ledger"`
+	Webhook       webhook.Config     `mapstructure:"webhook"`
+}
+
+// NetworkFamily returns the configured network family, falling back to the deprecated scheme.
+func (c *Config) NetworkFamily() types.Family {
+	if c.Family != "" {
+		return c.Family
+	}
+	return c.Scheme
+}
+
+// RpcUrls returns every configured rpc url, the single url first.
+func (c *Config) RpcUrls() []string {
+	urls := make([]string, 0, len(c.Urls)+1)
+	if c.Url != "" {
+		urls = append(urls, c.Url)
+	}
+	return append(urls, c.Urls...)
 }
 
 func LoadConfig(path string) (*Config, error) {
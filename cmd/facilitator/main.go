@@ -10,10 +10,16 @@ import (
 	"time"
 
 	"github.com/rabbitprincess/x402-facilitator/api"
+	"github.com/rabbitprincess/x402-facilitator/api/auth"
 	"github.com/rabbitprincess/x402-facilitator/facilitator"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	_ "modernc.org/sqlite"
 )
 
 var cmd = &cobra.Command{
@@ -28,6 +34,9 @@ var (
 	configPath string
 )
 
+// setupTimeout bounds the requests made to the chain while the facilitator is set up.
+const setupTimeout = 30 * time.Second
+
 func init() {
 	cmd.PersistentFlags().StringVarP(&configPath, "config", "c", "config.toml", "Path to the configuration file")
 }
@@ -45,12 +54,66 @@ func run() {
 	}
 	log.Logger = zerolog.New(os.Stdout).With().Timestamp().Caller().Logger()
 
-	facilitator, err := facilitator.NewFacilitator(config.Scheme, config.Network, config.Url, config.PrivateKey)
+	shutdownTracing, err := telemetry.Setup(context.Background(), config.Telemetry)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to init tracing, shutting down...")
+	}
+
+	registry := evm.NewRegistry()
+	if err := registry.Register(config.Chains); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register chains, shutting down...")
+	}
+
+	setupCtx, cancelSetup := context.WithTimeout(context.Background(), setupTimeout)
+	facilitator, err := facilitator.NewFacilitator(setupCtx, config.NetworkFamily(), config.Network, config.RpcUrls(), config.PrivateKey, facilitator.Options{
+		RPC:               config.RPC,
+		PaidResources:     config.PaidResources,
+		Policy:            config.Policy,
+		MinAmountRequired: config.MinAmountRequired,
+		DomainTTL:         config.DomainTTL,
+		Registry:          registry,
+	})
+	cancelSetup()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to init facilitator, shutting down...")
 	}
 
-	api := api.NewServer(facilitator)
+	authenticators, err := auth.NewAuthenticators(config.Auth)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to init authentication, shutting down...")
+	}
+
+	var paymentLedger *ledger.Ledger
+	if config.Ledger.Enabled {
+		paymentLedger, err = ledger.Open(context.Background(), config.Ledger)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open ledger, shutting down...")
+		}
+		defer paymentLedger.Close()
+	}
+
+	var webhooks *webhook.Dispatcher
+	if config.Webhook.Enabled {
+		// endpoints registered through the API and dead letters are kept in the ledger database if enabled
+		var store webhook.Store
+		if paymentLedger != nil {
+			store = paymentLedger.WebhookStore()
+		}
+		webhooks, err = webhook.NewDispatcher(context.Background(), config.Webhook, store)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to init webhooks, shutting down...")
+		}
+		defer webhooks.Close()
+	}
+
+	api := api.NewServer(facilitator, api.Options{
+		Authenticators: authenticators,
+		RateLimit:      config.RateLimit,
+		Idempotency:    config.Idempotency,
+		Ledger:         paymentLedger,
+		Webhooks:       webhooks,
+		Registry:       registry,
+	})
 
 	// Initialize Server
 	server := &http.Server{
@@ -77,5 +140,8 @@ func run() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Failed to shutdown server gracefully")
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
 	log.Info().Msg("Server shutdown gracefully")
 }
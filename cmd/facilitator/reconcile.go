@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/reconcile"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/rpcpool"
+	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Reconcile ledger settlements with on-chain transfers",
+	Long: `Reconcile the settlements recorded in the ledger with the EIP-3009 Transfer and
+AuthorizationUsed events of the configured network, and write a JSON report of the
+missing, extra and mismatched settlements. Reconciles the previous month by default.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReconcile(cmd.Context())
+	},
+}
+
+var reconcileFlags struct {
+	month  string
+	from   string
+	to     string
+	margin time.Duration
+	assets []string
+	payTo  []string
+	out    string
+}
+
+func init() {
+	flags := reconcileCmd.Flags()
+	flags.StringVar(&reconcileFlags.month, "month", "", "Month to reconcile as YYYY-MM, the previous month if no window is given")
+	flags.StringVar(&reconcileFlags.from, "from", "", "Start of the window, RFC3339 or YYYY-MM-DD")
+	flags.StringVar(&reconcileFlags.to, "to", "", "End of the window (exclusive), RFC3339 or YYYY-MM-DD")
+	flags.DurationVar(&reconcileFlags.margin, "margin", reconcile.DefaultMargin, "Margin scanned around the window")
+	flags.StringSliceVar(&reconcileFlags.assets, "asset", nil, "Token symbols or addresses to reconcile, every known token of the network by default")
+	flags.StringSliceVar(&reconcileFlags.payTo, "pay-to", nil, "Recipient addresses checked for extra transfers besides the ones in the ledger")
+	flags.StringVarP(&reconcileFlags.out, "out", "o", "", "Path of the report, stdout by default")
+	cmd.AddCommand(reconcileCmd)
+}
+
+func runReconcile(ctx context.Context) error {
+	log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if family := config.NetworkFamily(); family != types.EVM {
+		return fmt.Errorf("reconciliation is not supported for network family %s", family)
+	}
+	if !config.Ledger.Enabled {
+		return fmt.Errorf("reconciliation requires the ledger to be enabled")
+	}
+	registry := evm.NewRegistry()
+	if err := registry.Register(config.Chains); err != nil {
+		return fmt.Errorf("failed to register chains: %w", err)
+	}
+	from, to, err := reconcileWindow(time.Now())
+	if err != nil {
+		return err
+	}
+	assets, err := reconcileAssets(registry, config.Network, reconcileFlags.assets)
+	if err != nil {
+		return err
+	}
+	var payTo []common.Address
+	for _, address := range reconcileFlags.payTo {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid pay-to address %q", address)
+		}
+		payTo = append(payTo, common.HexToAddress(address))
+	}
+
+	urls := config.RpcUrls()
+	if len(urls) == 0 {
+		if chainInfo := registry.GetChainInfo(config.Network); chainInfo != nil {
+			urls = []string{chainInfo.DefaultUrl}
+		}
+	}
+	pool, err := rpcpool.Dial(ctx, urls, config.RPC)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ethereum client: %w", err)
+	}
+	defer pool.Close()
+
+	paymentLedger, err := ledger.Open(ctx, config.Ledger)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer paymentLedger.Close()
+
+	report, err := reconcile.New(paymentLedger, pool).Run(ctx, reconcile.Config{
+		Network: config.Network,
+		Assets:  assets,
+		From:    from,
+		To:      to,
+		Margin:  reconcileFlags.margin,
+		PayTo:   payTo,
+	})
+	if err != nil {
+		return err
+	}
+	log.Info().Time("from", from).Time("to", to).Int("settlements", report.Settlements).Int("matched", report.Matched).Int("unreconciled", report.Unreconciled).
+		Int("discrepancies", len(report.Discrepancies)).Msg("Reconciliation finished")
+
+	out := os.Stdout
+	if reconcileFlags.out != "" {
+		if out, err = os.Create(reconcileFlags.out); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// reconcileWindow returns the window selected by the flags, the month before now's by default.
+func reconcileWindow(now time.Time) (time.Time, time.Time, error) {
+	if reconcileFlags.from != "" || reconcileFlags.to != "" {
+		if reconcileFlags.month != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("--month cannot be combined with --from and --to")
+		}
+		from, err := parseTime(reconcileFlags.from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from: %w", err)
+		}
+		to := now
+		if reconcileFlags.to != "" {
+			if to, err = parseTime(reconcileFlags.to); err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid --to: %w", err)
+			}
+		}
+		return from, to, nil
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if reconcileFlags.month != "" {
+		var err error
+		if month, err = time.Parse("2006-01", reconcileFlags.month); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --month: %w", err)
+		}
+	}
+	return month, month.AddDate(0, 1, 0), nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// reconcileAssets resolves token symbols and addresses by registry, every known token of the network if none are given.
+func reconcileAssets(registry *evm.Registry, network string, values []string) ([]common.Address, error) {
+	var tokens map[string]evm.TokenInfo
+	if chainInfo := registry.GetChainInfo(network); chainInfo != nil {
+		tokens = chainInfo.TokenContracts
+	}
+	if len(values) == 0 {
+		for symbol := range tokens {
+			values = append(values, symbol)
+		}
+		slices.Sort(values)
+	}
+
+	var assets []common.Address
+	for _, value := range values {
+		if domain, ok := tokens[strings.ToUpper(value)]; ok {
+			assets = append(assets, domain.VerifyingContract)
+		} else if common.IsHexAddress(value) {
+			assets = append(assets, common.HexToAddress(value))
+		} else {
+			return nil, fmt.Errorf("unknown asset %q on network %s", value, network)
+		}
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("no assets to reconcile on network %s", network)
+	}
+	return assets, nil
+}
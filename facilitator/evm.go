@@ -4,216 +4,542 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"math/big"
+	"slices"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/rabbitprincess/x402-facilitator/paid"
+	"github.com/rabbitprincess/x402-facilitator/policy"
 	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
 	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip3009"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/rpcpool"
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
 	"github.com/rabbitprincess/x402-facilitator/types"
 )
 
 var _ Facilitator = (*EVMFacilitator)(nil)
 
+// Backend is the chain access EVMFacilitator reads and settles through: contract calls and transactions,
+// the chain ID and transaction receipts. ethclient.Client and simulated.Client implement it.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
 type EVMFacilitator struct {
-	scheme    types.Scheme
+	family    types.Family
 	network   string
 	networkID *big.Int
+	// registry resolves the network and its tokens
+	registry *evm.Registry
 
-	client  *ethclient.Client
+	client  Backend
 	signer  types.Signer
 	address common.Address
+	// now returns the current time
+	now func() time.Time
+
+	// resources tracks one-time resources, nil if disabled
+	resources *paid.Tracker
+	// minAmount is the minimum amount of a payment, nil if none
+	minAmount *big.Int
+	// policy rejects payments of disallowed payers and payees, nil if disabled
+	policy *policy.Engine
+	// domains caches the on-chain EIP-712 domains of the tokens, the registry's being used if nil
+	domains *evm.DomainCache
 }
 
-func NewEVMFacilitator(network string, url string, privateKeyHex string) (*EVMFacilitator, error) {
-	if network == "" && url == "" {
+// NewEVMFacilitator dials the rpc urls of network, the network's default url if none, and returns
+// a facilitator whose fee payer signs with the hex encoded private key. ctx bounds the requests made
+// to set up the facilitator.
+func NewEVMFacilitator(ctx context.Context, network string, urls []string, privateKeyHex string, opts Options) (*EVMFacilitator, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = evm.DefaultRegistry
+	}
+	if network == "" && len(urls) == 0 {
 		return nil, fmt.Errorf("network or rpc url must be provided")
-	} else if url == "" {
+	} else if len(urls) == 0 {
 		// if url is not provided, use default URL
-		if chainInfo := evm.GetChainInfo(network); chainInfo == nil {
+		if chainInfo := registry.GetChainInfo(network); chainInfo == nil {
 			return nil, fmt.Errorf("unsupported network name: %s", network)
 		} else {
-			url = chainInfo.DefaultUrl
+			urls = []string{chainInfo.DefaultUrl}
 		}
 	}
 
-	client, err := ethclient.Dial(url)
+	privateKey, err := hex.DecodeString(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+		return nil, err
 	}
-	networkId, err := client.NetworkID(context.Background())
+	address, err := evm.GetAddrssFromPrivateKey(privateKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get network ID: %w", err)
+		return nil, fmt.Errorf("failed to get address from private key: %w", err)
 	}
-	chainName := evm.GetChainName(networkId)
-	if chainName == "" || chainName != network {
-		return nil, fmt.Errorf("unsupported network: %s", network)
+	pool, err := rpcpool.Dial(ctx, urls, opts.RPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
 	}
 
-	privateKey, err := hex.DecodeString(privateKeyHex)
+	t, err := NewEVMFacilitatorWithBackend(ctx, pool, evm.NewRawPrivateSigner(privateKey), address, opts)
 	if err != nil {
+		pool.Close()
 		return nil, err
 	}
-	signer := evm.NewRawPrivateSigner(privateKey)
-	address, err := evm.GetAddrssFromPrivateKey(privateKey)
+	if t.network != network {
+		pool.Close()
+		return nil, fmt.Errorf("unsupported network: %s", network)
+	}
+	return t, nil
+}
+
+// NewEVMFacilitatorWithBackend returns a facilitator reading and settling through backend, whose fee payer
+// at address signs with signer. The network is the one of opts.Registry with the chain ID of backend.
+// ctx bounds the requests made to set up the facilitator. Every call to backend is traced.
+func NewEVMFacilitatorWithBackend(ctx context.Context, backend Backend, signer types.Signer, address common.Address, opts Options) (*EVMFacilitator, error) {
+	backend = newTracedClient(backend)
+	registry := opts.Registry
+	if registry == nil {
+		registry = evm.DefaultRegistry
+	}
+	chainID, err := backend.ChainID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get address from private key: %w", err)
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	network := registry.GetChainName(chainID)
+	if network == "" {
+		return nil, fmt.Errorf("unsupported chain ID: %s", chainID)
 	}
 
-	return &EVMFacilitator{
-		scheme:    types.EVM,
+	var resources *paid.Tracker
+	if opts.PaidResources.Enabled {
+		resources = paid.NewTracker(opts.PaidResources, opts.PaidResourceStore)
+	}
+	var minAmount *big.Int
+	if opts.MinAmountRequired != "" {
+		var ok bool
+		if minAmount, ok = new(big.Int).SetString(opts.MinAmountRequired, 10); !ok || minAmount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid minimum amount: %s", opts.MinAmountRequired)
+		}
+	}
+	var engine *policy.Engine
+	if opts.Policy.Enabled {
+		if engine, err = policy.New(opts.Policy, opts.Screener); err != nil {
+			return nil, fmt.Errorf("failed to load policy: %w", err)
+		}
+	}
+	now := opts.Clock
+	if now == nil {
+		now = time.Now
+	}
+
+	t := &EVMFacilitator{
+		family:    types.EVM,
 		network:   network,
-		networkID: networkId,
+		networkID: chainID,
+		registry:  registry,
 
-		client:  client,
+		client:  backend,
 		signer:  signer,
 		address: address,
-	}, nil
+		now:     now,
+
+		resources: resources,
+		minAmount: minAmount,
+		policy:    engine,
+		domains:   evm.NewDomainCache(backend, opts.DomainTTL),
+	}
+	if err := t.checkSigner(); err != nil {
+		return nil, fmt.Errorf("invalid fee payer: %w", err)
+	}
+	t.checkDomains(ctx)
+	return t, nil
+}
+
+// checkDomains fetches the on-chain EIP-712 domains of the network's tokens and logs those differing from the registry,
+// whose signatures would otherwise fail to verify on-chain.
+func (t *EVMFacilitator) checkDomains(ctx context.Context) {
+	tokens := t.registry.GetTokens(t.network)
+	for _, symbol := range slices.Sorted(maps.Keys(tokens)) {
+		expected := tokens[symbol].DomainConfig
+		onchain, err := t.domains.Domain(ctx, &expected)
+		if err == nil {
+			err = evm.CheckDomain(&expected, onchain)
+		}
+		if errors.Is(err, evm.ErrDomainMismatch) {
+			log.Error().Err(err).Str("network", t.network).Str("token", symbol).Msg("EIP-712 domain of token differs from the registry, using the on-chain domain")
+		} else if err != nil {
+			log.Warn().Err(err).Str("network", t.network).Str("token", symbol).Msg("Failed to fetch EIP-712 domain of token")
+		}
+	}
 }
 
 // verification steps:
 //   - ✅ verify payload format
 //   - ✅ verify payload version
 //   - ✅ verify usdc address is correct for the chain
-//   - ✅ verify permit signature
+//   - ✅ verify authorization signature
+//   - ✅ verify authorization recipient is paymentRequirements.payTo
 //   - ✅ verify deadline
 //   - verify nonce is current
 //   - ✅ verify client has enough funds to cover paymentRequirements.maxAmountRequired
 //   - ✅ verify value in payload is enough to cover paymentRequirements.maxAmountRequired
-//   - check min amount is above some threshold we think is reasonable for covering gas
-//   - verify resource is not already paid for (next version)
+//   - ✅ check min amount is above the threshold configured for covering gas
+//   - ✅ verify one-time resource is not already paid for
+//   - ✅ verify payer and payee are allowed by the policy
 func (t *EVMFacilitator) Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
-	// Step 1: Payload format
-	var evmPayload evm.EVMPayload
-	if err := json.Unmarshal([]byte(payload.Payload), &evmPayload); err != nil {
-		return &types.PaymentVerifyResponse{
-			IsValid:       false,
-			InvalidReason: types.ErrInvalidPayloadFormat.Error(),
-		}, nil
+	ctx, span := telemetry.Tracer().Start(ctx, "EVMFacilitator.Verify", trace.WithAttributes(
+		telemetry.AttrScheme.String(payload.Scheme),
+		telemetry.AttrNetwork.String(payload.Network),
+	))
+	defer span.End()
+
+	res, err := t.verify(ctx, payload, req)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
 	}
+	span.SetAttributes(
+		telemetry.AttrIsValid.Bool(res.IsValid),
+		telemetry.AttrInvalidReason.String(res.InvalidReason),
+		telemetry.AttrPayer.String(res.Payer),
+	)
+	return res, nil
+}
 
-	// Step 2: Scheme verification
-	if payload.Scheme != string(t.scheme) || req.Scheme != string(t.scheme) {
-		return &types.PaymentVerifyResponse{
-			IsValid:       false,
-			InvalidReason: types.ErrIncompatibleScheme.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
-		}, nil
+// stepTracer records each verification step as a child span.
+// Starting a step ends the previous one, so early returns only need a deferred end.
+type stepTracer struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+func (s *stepTracer) start(name string) context.Context {
+	s.end()
+	ctx, span := telemetry.Tracer().Start(s.ctx, "verify."+name)
+	s.span = span
+	return ctx
+}
+
+func (s *stepTracer) end() {
+	if s.span != nil {
+		s.span.End()
+		s.span = nil
 	}
+}
 
-	// Step 3: Network info and Contract info
-	if payload.Network != t.network {
+func (t *EVMFacilitator) verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	scheme, reason := schemeOf(payload, req)
+	if reason != nil {
 		return &types.PaymentVerifyResponse{
 			IsValid:       false,
-			InvalidReason: types.ErrNetworkMismatch.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
+			InvalidReason: reason.Error(),
 		}, nil
 	}
-	chainID := evm.GetChainID(payload.Network)
-	if chainID == nil {
-		return &types.PaymentVerifyResponse{
-			IsValid:       false,
-			InvalidReason: types.ErrInvalidNetwork.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
-		}, nil
+	return scheme.verify(t, ctx, payload, req)
+}
+
+// evmScheme verifies and settles the payments of a scheme.
+type evmScheme struct {
+	verify func(t *EVMFacilitator, ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error)
+	settle func(t *EVMFacilitator, ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error)
+}
+
+// evmSchemes are the schemes served with each protocol version.
+// A new version routes its payloads to its own parsers by adding its schemes, while payments of older versions are still served.
+var evmSchemes = map[types.X402Version]map[types.Scheme]evmScheme{
+	types.X402VersionV1: {
+		types.SchemeExact: {verify: (*EVMFacilitator).verifyExact, settle: (*EVMFacilitator).settleExact},
+		types.SchemeUpto:  {verify: (*EVMFacilitator).verifyUpto, settle: (*EVMFacilitator).settleUpto},
+	},
+}
+
+// schemeOf returns the scheme serving the payment's version and scheme, or the reason it is not served.
+func schemeOf(payload *types.PaymentPayload, req *types.PaymentRequirements) (evmScheme, error) {
+	schemes, ok := evmSchemes[types.X402Version(payload.X402Version)]
+	if !ok {
+		return evmScheme{}, types.ErrInvalidX402Version
 	}
-	if chainID.Cmp(t.networkID) != 0 {
+	if payload.Scheme != req.Scheme {
+		return evmScheme{}, types.ErrInvalidScheme
+	}
+	scheme, ok := schemes[types.Scheme(req.Scheme)]
+	if !ok {
+		return evmScheme{}, types.ErrUnsupportedScheme
+	}
+	return scheme, nil
+}
+
+// validityBuffer is the time left to settle an authorization before it expires, about a few blocks.
+const validityBuffer = 6 * time.Second
+
+func (t *EVMFacilitator) verifyExact(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	steps := &stepTracer{ctx: ctx}
+	defer steps.end()
+
+	// Step 1: Payload format
+	steps.start("payload_format")
+	var evmPayload evm.EVMPayload
+	if err := json.Unmarshal([]byte(payload.Payload), &evmPayload); err != nil || !validAuthorization(evmPayload.Authorization) {
 		return &types.PaymentVerifyResponse{
 			IsValid:       false,
-			InvalidReason: types.ErrNetworkIDMismatch.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
+			InvalidReason: types.ErrInvalidPayload.Error(),
 		}, nil
 	}
-	domainConfig := evm.GetDomainConfig(payload.Network, req.Asset)
-	if domainConfig == nil {
+	authorization := evmPayload.Authorization
+	invalid := func(reason error) (*types.PaymentVerifyResponse, error) {
 		return &types.PaymentVerifyResponse{
 			IsValid:       false,
-			InvalidReason: types.ErrTokenMismatch.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
+			InvalidReason: reason.Error(),
+			Payer:         authorization.From.String(),
 		}, nil
 	}
 
-	// Step 4: Verify signature (EIP-712)
-	sig, err := evm.ParseSignature(evmPayload.Signature)
-	if err != nil {
-		return nil, err
+	// Step 2: Payment requirements
+	steps.start("requirements")
+	maxAmount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok || maxAmount.Sign() < 0 || !common.IsHexAddress(req.PayTo) {
+		return invalid(types.ErrInvalidPaymentRequirements)
 	}
-	digest := evmPayload.Authorization.ToMessageHash()
-	pubkey, err := evm.Ecrecover(digest, sig)
+
+	// Step 3: Network info and Contract info
+	steps.start("network")
+	domainConfig, reason := t.domainOf(ctx, payload, req)
+	if reason != nil {
+		return invalid(reason)
+	}
+
+	// Step 4: Verify signature of the authorizer (EIP-712)
+	steps.start("signature")
+	sig, err := evm.ParseSignature(evmPayload.Signature)
 	if err != nil {
-		return nil, err
+		return invalid(types.ErrInvalidExactEvmPayloadSignature)
 	}
-	if valid := evm.VerifySignature(pubkey, digest, sig[:64]); !valid {
-		return &types.PaymentVerifyResponse{
-			IsValid:       false,
-			InvalidReason: types.ErrInvalidSignature.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
-		}, nil
+	signer, err := evm.RecoverAddress(evm.HashEip3009(authorization, domainConfig), sig)
+	if err != nil || signer != authorization.From {
+		return invalid(types.ErrInvalidExactEvmPayloadSignature)
 	}
 
 	// Step 5: Validate payTo
+	if authorization.To != common.HexToAddress(req.PayTo) {
+		return invalid(types.ErrInvalidExactEvmPayloadRecipientMismatch)
+	}
 
-	// Step 6: Deadline check
+	// Step 6: Deadline check, leaving time to settle before the authorization expires
+	now := t.now()
+	if authorization.ValidBefore.Cmp(big.NewInt(now.Add(validityBuffer).Unix())) < 0 {
+		return invalid(types.ErrInvalidExactEvmPayloadAuthorizationValidBefore)
+	}
+	if authorization.ValidAfter.Cmp(big.NewInt(now.Unix())) > 0 {
+		return invalid(types.ErrInvalidExactEvmPayloadAuthorizationValidAfter)
+	}
 
 	// Step 7: TODO: Nonce freshness check (optional in v1)
 
 	// Step 8: Check ERC20 balance
+	balanceCtx := steps.start("balance")
 	contract, err := eip3009.NewEip3009(domainConfig.VerifyingContract, t.client)
 	if err != nil {
 		return nil, fmt.Errorf("contract bind failed: %w", err)
 	}
-	balance, err := contract.BalanceOf(&bind.CallOpts{Context: ctx}, evmPayload.Authorization.From)
+	balance, err := contract.BalanceOf(&bind.CallOpts{Context: balanceCtx}, authorization.From)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
-	if balance.Cmp(evmPayload.Authorization.Value) < 0 {
-		return &types.PaymentVerifyResponse{
-			IsValid:       false,
-			InvalidReason: types.ErrInsufficientBalance.Error(),
-			Payer:         evmPayload.Authorization.From.String(),
-		}, nil
+	if balance.Cmp(authorization.Value) < 0 {
+		return invalid(types.ErrInsufficientFunds)
 	}
 
-	// Step 9: Check value in permit matches requirement
+	// Step 9: Check value in authorization covers the requirement
+	steps.start("value")
+	if authorization.Value.Cmp(maxAmount) < 0 {
+		return invalid(types.ErrInvalidExactEvmPayloadAuthorizationValue)
+	}
+
+	// Step 10: Check minimum payment threshold covering the gas of the settlement
+	if t.minAmount != nil && authorization.Value.Cmp(t.minAmount) < 0 {
+		return invalid(types.ErrInvalidExactEvmPayloadAuthorizationValue)
+	}
 
-	// Step 10: TODO: Check minimum payment threshold (e.g. for gas overhead)
+	// Step 11: Check if a one-time resource is already paid
+	if t.resources != nil {
+		resourceCtx := steps.start("resource")
+		receipt, err := t.resources.Paid(resourceCtx, req.Resource, authorization.From.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check paid resources: %w", err)
+		}
+		if receipt != nil {
+			return &types.PaymentVerifyResponse{
+				IsValid:       false,
+				InvalidReason: types.ErrResourceAlreadyPaid.Error(),
+				Payer:         authorization.From.String(),
+				Receipt:       receipt,
+			}, nil
+		}
+	}
 
-	// Step 11: TODO: Check if resource already paid (next version)
+	// Step 12: Check the policy allows the payer and payee
+	if t.policy != nil {
+		allowed, err := t.allowed(steps.start("policy"), authorization.From, authorization.To)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return invalid(types.ErrPolicyViolation)
+		}
+	}
 
 	// ✅ All checks passed
 	return &types.PaymentVerifyResponse{
 		IsValid: true,
-		Payer:   evmPayload.Authorization.From.String(),
+		Payer:   authorization.From.String(),
 	}, nil
 }
 
-func (t *EVMFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
-	var evmPayload evm.EVMPayload
-	if err := json.Unmarshal([]byte(payload.Payload), &evmPayload); err != nil {
+// allowed reports whether the policy allows a payment from payer to payee, logging why it does not.
+func (t *EVMFacilitator) allowed(ctx context.Context, payer, payee common.Address) (bool, error) {
+	err := t.policy.Check(ctx, payer.Hex(), payee.Hex())
+	if errors.Is(err, types.ErrPolicyViolation) {
+		log.Ctx(ctx).Warn().Err(err).Msg("Payment rejected by policy")
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check policy: %w", err)
+	}
+	return true, nil
+}
+
+// reserveResource reserves the one-time resource of req for the settlement of payer until its transaction is mined,
+// for concurrent settlements not to both pay for it. It returns the failed settlement if payer already paid for it
+// or another of its settlements is pending.
+func (t *EVMFacilitator) reserveResource(ctx context.Context, req *types.PaymentRequirements, payer string) (*types.PaymentSettleResponse, error) {
+	if t.resources == nil {
+		return nil, nil
+	}
+	reserved, err := t.resources.Reserve(ctx, req.Resource, payer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve paid resource: %w", err)
+	}
+	if !reserved {
 		return &types.PaymentSettleResponse{
-			Success: false,
-			Error:   types.ErrInvalidPayloadFormat.Error(),
+			Success:     false,
+			ErrorReason: types.ErrResourceAlreadyPaid.Error(),
+			Payer:       payer,
+			Network:     req.Network,
 		}, nil
 	}
+	return nil, nil
+}
+
+// releaseResource releases the one-time resource reserved for a settlement by payer that failed.
+func (t *EVMFacilitator) releaseResource(ctx context.Context, resource, payer string) {
+	if t.resources == nil {
+		return
+	}
+	if err := t.resources.Release(ctx, resource, payer); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("resource", resource).Msg("Failed to release paid resource")
+	}
+}
+
+// recordResource records the one-time resource of req as paid by payer once the transaction of hash succeeds,
+// releasing it if the transaction reverts. The transaction is waited for in the background, the settlement
+// returning once it is sent.
+func (t *EVMFacilitator) recordResource(ctx context.Context, req *types.PaymentRequirements, payer string, hash common.Hash) {
+	if t.resources == nil {
+		return
+	}
+	receipt := &types.PaymentReceipt{
+		Resource: req.Resource,
+		Payer:    payer,
+		Network:  req.Network,
+		TxHash:   hash.Hex(),
+		PaidAt:   t.now().UTC(),
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), paid.PendingTTL)
+	go func() {
+		defer cancel()
+		mined, err := bind.WaitMined(ctx, t.client, hash)
+		if err != nil {
+			// the reservation expires after the pending ttl, the transaction may still be mined
+			log.Ctx(ctx).Error().Err(err).Str("tx", receipt.TxHash).Msg("Failed to wait for the settlement of a paid resource")
+			return
+		}
+		if mined.Status != ethTypes.ReceiptStatusSuccessful {
+			t.releaseResource(ctx, receipt.Resource, payer)
+			return
+		}
+		if err := t.resources.Record(ctx, receipt); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("resource", receipt.Resource).Msg("Failed to record paid resource")
+		}
+	}()
+}
+
+func validAuthorization(authorization *evm.Authorization) bool {
+	return authorization != nil && authorization.Value != nil && authorization.ValidAfter != nil && authorization.ValidBefore != nil
+}
+
+func (t *EVMFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "EVMFacilitator.Settle", trace.WithAttributes(
+		telemetry.AttrScheme.String(payload.Scheme),
+		telemetry.AttrNetwork.String(payload.Network),
+	))
+	defer span.End()
+
+	res, err := t.settle(ctx, payload, req)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(telemetry.AttrTxHash.String(res.Transaction))
+	return res, nil
+}
 
-	networkID := evm.GetChainID(req.Network)
-	if networkID == nil {
+func (t *EVMFacilitator) settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	scheme, reason := schemeOf(payload, req)
+	if reason != nil {
 		return &types.PaymentSettleResponse{
-			Success: false,
-			Error:   types.ErrInvalidNetwork.Error(),
+			Success:     false,
+			ErrorReason: reason.Error(),
+			Network:     req.Network,
 		}, nil
 	}
+	return scheme.settle(t, ctx, payload, req)
+}
 
-	domainConfig := evm.GetDomainConfig(payload.Network, req.Asset)
-	if domainConfig == nil {
+func (t *EVMFacilitator) settleExact(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	// the payment is verified again, it may have expired or been spent since its verification
+	verified, err := t.verifyExact(ctx, payload, req)
+	if err != nil {
+		return nil, err
+	}
+	if !verified.IsValid {
 		return &types.PaymentSettleResponse{
-			Success: false,
-			Error:   types.ErrTokenMismatch.Error(),
+			Success:     false,
+			ErrorReason: verified.InvalidReason,
+			Payer:       verified.Payer,
+			Network:     req.Network,
 		}, nil
 	}
+
+	var evmPayload evm.EVMPayload
+	if err := json.Unmarshal([]byte(payload.Payload), &evmPayload); err != nil {
+		return nil, err
+	}
+	networkID := t.registry.GetChainID(req.Network)
+	domainConfig, reason := t.domainOf(ctx, payload, req)
+	if reason != nil {
+		return nil, reason
+	}
 	contract, err := eip3009.NewEip3009(domainConfig.VerifyingContract, t.client)
 	if err != nil {
 		return nil, fmt.Errorf("contract bind failed: %w", err)
@@ -222,6 +548,10 @@ func (t *EVMFacilitator) Settle(ctx context.Context, payload *types.PaymentPaylo
 	if err != nil {
 		return nil, err
 	}
+	payer := evmPayload.Authorization.From.String()
+	if res, err := t.reserveResource(ctx, req, payer); res != nil || err != nil {
+		return res, err
+	}
 
 	tx, err := contract.TransferWithAuthorization(
 		&bind.TransactOpts{
@@ -238,21 +568,93 @@ func (t *EVMFacilitator) Settle(ctx context.Context, payload *types.PaymentPaylo
 		clientSig,
 	)
 	if err != nil {
+		t.releaseResource(ctx, req.Resource, payer)
 		return nil, fmt.Errorf("failed to transfer with authorization %w", err)
 	}
+	t.recordResource(ctx, req, payer, tx.Hash())
 
 	return &types.PaymentSettleResponse{
-		Success:   true,
-		TxHash:    tx.Hash().Hex(),
-		NetworkId: fmt.Sprintf("%d", networkID),
+		Success:     true,
+		Payer:       payer,
+		Transaction: tx.Hash().Hex(),
+		Network:     req.Network,
+		Amount:      evmPayload.Authorization.Value.String(),
 	}, nil
 }
 
+// domainOf returns the EIP-712 domain of the required asset, or the reason the payment's network or asset is not served.
+// The domain is read on-chain, falling back to the registry if it cannot be fetched.
+func (t *EVMFacilitator) domainOf(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*evm.DomainConfig, error) {
+	if payload.Network != req.Network || payload.Network != t.network {
+		return nil, types.ErrInvalidNetwork
+	}
+	chainID := t.registry.GetChainID(payload.Network)
+	if chainID == nil {
+		return nil, types.ErrInvalidNetwork
+	}
+	if chainID.Cmp(t.networkID) != 0 {
+		return nil, types.ErrInvalidNetwork
+	}
+	domainConfig := t.registry.GetDomainConfig(payload.Network, req.Asset)
+	if domainConfig == nil {
+		return nil, types.ErrInvalidPaymentRequirements
+	}
+	if t.domains == nil {
+		return domainConfig, nil
+	}
+	onchain, err := t.domains.Domain(ctx, domainConfig)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("asset", req.Asset).Msg("Failed to fetch EIP-712 domain of token, using the registry")
+		return domainConfig, nil
+	}
+	return onchain, nil
+}
+
 func (t *EVMFacilitator) Supported() []*types.SupportedKind {
-	return []*types.SupportedKind{
-		{
-			Scheme:  string(t.scheme),
-			Network: t.network,
-		},
+	// upto permits are granted to the facilitator, which transfers the settled amount
+	extra := json.RawMessage(fmt.Sprintf(`{"spender":%q}`, t.address.Hex()))
+	assets := t.supportedAssets()
+	var kinds []*types.SupportedKind
+	for _, version := range slices.Sorted(maps.Keys(evmSchemes)) {
+		for _, scheme := range slices.Sorted(maps.Keys(evmSchemes[version])) {
+			kind := &types.SupportedKind{
+				X402Version: int(version),
+				Scheme:      string(scheme),
+				Network:     t.network,
+				FeePayer:    t.address.Hex(),
+				Assets:      assets,
+			}
+			if scheme == types.SchemeUpto {
+				kind.Extra = &extra
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// supportedAssets returns the tokens accepted on the network ordered by symbol.
+func (t *EVMFacilitator) supportedAssets() []types.SupportedAsset {
+	tokens := t.registry.GetTokens(t.network)
+	assets := make([]types.SupportedAsset, 0, len(tokens))
+	for _, symbol := range slices.Sorted(maps.Keys(tokens)) {
+		token := tokens[symbol]
+		if t.domains != nil {
+			if onchain := t.domains.Cached(token.VerifyingContract); onchain != nil {
+				token.DomainConfig = *onchain
+			}
+		}
+		asset := types.SupportedAsset{
+			Address:  token.VerifyingContract.Hex(),
+			Symbol:   symbol,
+			Decimals: int(token.Decimals),
+			Name:     token.Name,
+			Version:  token.Version,
+		}
+		if t.minAmount != nil {
+			asset.MinAmountRequired = t.minAmount.String()
+		}
+		assets = append(assets, asset)
 	}
+	return assets
 }
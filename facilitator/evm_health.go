@@ -0,0 +1,81 @@
+package facilitator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var _ HealthChecker = (*EVMFacilitator)(nil)
+
+// DefaultMaxBlockAge is the age after which the latest block is considered stale.
+const DefaultMaxBlockAge = 2 * time.Minute
+
+// HealthCheck probes the RPC node, chain ID, fee payer signer and chain head.
+func (t *EVMFacilitator) HealthCheck(ctx context.Context) []*types.NetworkHealth {
+	health := &types.NetworkHealth{
+		Family:  string(t.family),
+		Network: t.network,
+	}
+	addCheck := func(name string, err error) {
+		check := types.HealthCheck{Name: name, Healthy: err == nil}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		health.Checks = append(health.Checks, check)
+	}
+
+	// RPC reachable and serving the expected chain
+	chainID, err := t.client.ChainID(ctx)
+	addCheck("rpc", err)
+	if err == nil {
+		health.ChainID = chainID.String()
+		if expected := t.registry.GetChainID(t.network); expected == nil || expected.Cmp(chainID) != 0 {
+			addCheck("chain_id", fmt.Errorf("expected chain id %v, got %v", expected, chainID))
+		} else {
+			addCheck("chain_id", nil)
+		}
+	}
+
+	// Fee payer signer usable
+	addCheck("signer", t.checkSigner())
+
+	// Chain head not stale
+	header, err := t.client.HeaderByNumber(ctx, nil)
+	if err == nil {
+		health.LatestBlock = header.Number.Uint64()
+		health.LatestBlockAge = t.now().Unix() - int64(header.Time)
+		if age := time.Duration(health.LatestBlockAge) * time.Second; age > DefaultMaxBlockAge {
+			err = fmt.Errorf("latest block %d is stale (%s old)", health.LatestBlock, age)
+		}
+	}
+	addCheck("block", err)
+
+	health.Healthy = true
+	for _, check := range health.Checks {
+		health.Healthy = health.Healthy && check.Healthy
+	}
+	return []*types.NetworkHealth{health}
+}
+
+// checkSigner signs a fixed digest and verifies that it recovers to the fee payer address.
+func (t *EVMFacilitator) checkSigner() error {
+	digest := evm.Keccak256([]byte("x402-facilitator health check"))
+	sig, err := t.signer(digest)
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	pubkey, err := evm.Ecrecover(digest, sig)
+	if err != nil {
+		return fmt.Errorf("failed to recover signer: %w", err)
+	}
+	if address := common.BytesToAddress(evm.Keccak256(pubkey[1:])[12:]); address != t.address {
+		return fmt.Errorf("signer address %s does not match fee payer %s", address, t.address)
+	}
+	return nil
+}
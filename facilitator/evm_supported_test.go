@@ -0,0 +1,47 @@
+package facilitator
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+func TestEVMSupported(t *testing.T) {
+	facilitator := &EVMFacilitator{
+		network:   "base-sepolia",
+		registry:  evm.NewRegistry(),
+		address:   common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+		minAmount: big.NewInt(1000),
+	}
+
+	kinds := facilitator.Supported()
+	require.Len(t, kinds, 2)
+	for _, kind := range kinds {
+		require.Equal(t, int(types.X402VersionV1), kind.X402Version)
+		require.Equal(t, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", kind.FeePayer)
+		require.Equal(t, []types.SupportedAsset{{
+			Address:           "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+			Symbol:            "USDC",
+			Decimals:          6,
+			Name:              "USDC",
+			Version:           "2",
+			MinAmountRequired: "1000",
+		}}, kind.Assets)
+	}
+	require.Equal(t, string(types.SchemeExact), kinds[0].Scheme)
+	require.Nil(t, kinds[0].Extra)
+
+	// upto permits are granted to the fee payer
+	require.Equal(t, string(types.SchemeUpto), kinds[1].Scheme)
+	var extra struct {
+		Spender string `json:"spender"`
+	}
+	require.NoError(t, json.Unmarshal(*kinds[1].Extra, &extra))
+	require.Equal(t, kinds[1].FeePayer, extra.Spender)
+}
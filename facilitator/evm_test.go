@@ -1,83 +1,386 @@
 package facilitator
 
 import (
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rabbitprincess/x402-facilitator/paid"
+	"github.com/rabbitprincess/x402-facilitator/policy"
 	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/evmtest"
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
 	"github.com/rabbitprincess/x402-facilitator/types"
-	"github.com/stretchr/testify/require"
 )
 
 const (
-	PrivateKey = ""
-	Network    = "base-sepolia"
-	Token      = "USDC"
+	Network  = evmtest.Network
+	Token    = "USDC"
+	Resource = "https://example.com/resource"
+	// PayerKey is the key of the payer, fixed to be listed before the chain starts
+	PayerKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
 )
 
-func TestEVMVerify(t *testing.T) {
-	facilitator, err := NewEVMFacilitator(Network, "", PrivateKey)
-	require.NoError(t, err)
+// testAccount is a key of the simulated chain with its address
+type testAccount struct {
+	key     []byte
+	address common.Address
+}
 
-	privKey, err := hex.DecodeString("")
+func newTestAccount(t *testing.T) testAccount {
+	key, err := crypto.GenerateKey()
 	require.NoError(t, err)
-	evmPayload, err := evm.NewEVMPayload(Network, Token,
-		"", "", "10000", evm.NewRawPrivateSigner(privKey))
+	return testAccount{key: crypto.FromECDSA(key), address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// evmHarness is a facilitator settling on a simulated chain, whose payer holds 10000 of the mock token
+type evmHarness struct {
+	chain       *evmtest.Chain
+	facilitator *EVMFacilitator
+	feePayer    testAccount
+	payer       testAccount
+	payTo       common.Address
+}
+
+func newEVMHarness(t *testing.T, opts Options) *evmHarness {
+	payer, err := crypto.HexToECDSA(PayerKey)
 	require.NoError(t, err)
+	h := &evmHarness{
+		feePayer: newTestAccount(t),
+		payer:    testAccount{key: crypto.FromECDSA(payer), address: crypto.PubkeyToAddress(payer.PublicKey)},
+		payTo:    newTestAccount(t).address,
+	}
+	h.chain = evmtest.NewChain(t, h.feePayer.address, h.payer.address)
+	h.chain.Mint(t, h.payer.address, big.NewInt(10000))
 
-	evmPayloadJson, err := json.Marshal(evmPayload)
+	opts.Registry = h.chain.Registry
+	h.facilitator, err = NewEVMFacilitatorWithBackend(t.Context(), h.chain.Client, evm.NewRawPrivateSigner(h.feePayer.key), h.feePayer.address, opts)
 	require.NoError(t, err)
+	return h
+}
 
-	payload := &types.PaymentPayload{
+// payment returns a payment of value from the payer to payTo signed by signer, edited by edit before signing
+func (h *evmHarness) payment(t *testing.T, value int64, signer []byte, edit func(*evm.Authorization)) *types.PaymentPayload {
+	authorization := evm.NewAuthorization(h.payer.address.Hex(), h.payTo.Hex(), big.NewInt(value))
+	if edit != nil {
+		edit(authorization)
+	}
+	signature, err := evm.SignEip3009(authorization, h.chain.Registry.GetDomainConfig(Network, Token), evm.NewRawPrivateSigner(signer))
+	require.NoError(t, err)
+	payload, err := json.Marshal(&evm.EVMPayload{Signature: signature, Authorization: authorization})
+	require.NoError(t, err)
+	return &types.PaymentPayload{
 		X402Version: int(types.X402VersionV1),
-		Scheme:      string(types.EVM),
+		Scheme:      string(types.SchemeExact),
 		Network:     Network,
-		Payload:     evmPayloadJson,
+		Payload:     payload,
 	}
+}
+
+func (h *evmHarness) requirements(maxAmount string) *types.PaymentRequirements {
+	return &types.PaymentRequirements{
+		Scheme:            string(types.SchemeExact),
+		Network:           Network,
+		MaxAmountRequired: maxAmount,
+		Resource:          Resource,
+		PayTo:             h.payTo.Hex(),
+		Asset:             h.chain.Token.Hex(),
+	}
+}
+
+func TestEVMVerify(t *testing.T) {
+	h := newEVMHarness(t, Options{MinAmountRequired: "100"})
+	ctx := t.Context()
+
+	res, err := h.facilitator.Verify(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
+	require.NoError(t, err)
+	require.True(t, res.IsValid, res.InvalidReason)
+	require.Equal(t, h.payer.address.Hex(), res.Payer)
+
+	for _, tc := range []struct {
+		name    string
+		payload func() *types.PaymentPayload
+		req     func(req *types.PaymentRequirements)
+		reason  error
+	}{
+		{
+			name: "malformed payload",
+			payload: func() *types.PaymentPayload {
+				payload := h.payment(t, 1000, h.payer.key, nil)
+				payload.Payload = json.RawMessage(`{"signature":"0x"}`)
+				return payload
+			},
+			reason: types.ErrInvalidPayload,
+		},
+		{
+			name: "unknown version",
+			payload: func() *types.PaymentPayload {
+				payload := h.payment(t, 1000, h.payer.key, nil)
+				payload.X402Version = 99
+				return payload
+			},
+			reason: types.ErrInvalidX402Version,
+		},
+		{
+			name:   "scheme differing from the requirements",
+			req:    func(req *types.PaymentRequirements) { req.Scheme = string(types.SchemeUpto) },
+			reason: types.ErrInvalidScheme,
+		},
+		{
+			name: "unsupported scheme",
+			payload: func() *types.PaymentPayload {
+				payload := h.payment(t, 1000, h.payer.key, nil)
+				payload.Scheme = "stream"
+				return payload
+			},
+			req:    func(req *types.PaymentRequirements) { req.Scheme = "stream" },
+			reason: types.ErrUnsupportedScheme,
+		},
+		{
+			name:   "other network",
+			req:    func(req *types.PaymentRequirements) { req.Network = "base-sepolia" },
+			reason: types.ErrInvalidNetwork,
+		},
+		{
+			name:   "unknown asset",
+			req:    func(req *types.PaymentRequirements) { req.Asset = h.payTo.Hex() },
+			reason: types.ErrInvalidPaymentRequirements,
+		},
+		{
+			name:   "malformed amount",
+			req:    func(req *types.PaymentRequirements) { req.MaxAmountRequired = "ten" },
+			reason: types.ErrInvalidPaymentRequirements,
+		},
+		{
+			name:    "signed by another account",
+			payload: func() *types.PaymentPayload { return h.payment(t, 1000, h.feePayer.key, nil) },
+			reason:  types.ErrInvalidExactEvmPayloadSignature,
+		},
+		{
+			name:   "other recipient",
+			req:    func(req *types.PaymentRequirements) { req.PayTo = h.feePayer.address.Hex() },
+			reason: types.ErrInvalidExactEvmPayloadRecipientMismatch,
+		},
+		{
+			name: "expired authorization",
+			payload: func() *types.PaymentPayload {
+				return h.payment(t, 1000, h.payer.key, func(a *evm.Authorization) {
+					a.ValidBefore = big.NewInt(time.Now().Add(time.Second).Unix())
+				})
+			},
+			reason: types.ErrInvalidExactEvmPayloadAuthorizationValidBefore,
+		},
+		{
+			name: "authorization not yet valid",
+			payload: func() *types.PaymentPayload {
+				return h.payment(t, 1000, h.payer.key, func(a *evm.Authorization) {
+					a.ValidAfter = big.NewInt(time.Now().Add(time.Minute).Unix())
+				})
+			},
+			reason: types.ErrInvalidExactEvmPayloadAuthorizationValidAfter,
+		},
+		{
+			name:    "value over the balance",
+			payload: func() *types.PaymentPayload { return h.payment(t, 20000, h.payer.key, nil) },
+			req:     func(req *types.PaymentRequirements) { req.MaxAmountRequired = "20000" },
+			reason:  types.ErrInsufficientFunds,
+		},
+		{
+			name:    "value under the required amount",
+			payload: func() *types.PaymentPayload { return h.payment(t, 999, h.payer.key, nil) },
+			reason:  types.ErrInvalidExactEvmPayloadAuthorizationValue,
+		},
+		{
+			name:    "value under the minimum amount",
+			payload: func() *types.PaymentPayload { return h.payment(t, 50, h.payer.key, nil) },
+			req:     func(req *types.PaymentRequirements) { req.MaxAmountRequired = "50" },
+			reason:  types.ErrInvalidExactEvmPayloadAuthorizationValue,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := h.payment(t, 1000, h.payer.key, nil)
+			if tc.payload != nil {
+				payload = tc.payload()
+			}
+			req := h.requirements("1000")
+			if tc.req != nil {
+				tc.req(req)
+			}
+			res, err := h.facilitator.Verify(ctx, payload, req)
+			require.NoError(t, err)
+			require.False(t, res.IsValid)
+			require.Equal(t, tc.reason.Error(), res.InvalidReason)
+		})
+	}
+}
+
+// TestEVMVerifyReference verifies the example payment of the x402 exact scheme specification on a chain
+// standing for its network, at a time the payment is valid.
+func TestEVMVerifyReference(t *testing.T) {
+	data, err := os.ReadFile("../scheme/evm/testdata/exact_payment_payload.json")
+	require.NoError(t, err)
+	var payment types.PaymentPayload
+	require.NoError(t, json.Unmarshal(data, &payment))
+	var payload evm.EVMPayload
+	require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+	authorization := payload.Authorization
+
+	feePayer := newTestAccount(t)
+	chain := evmtest.NewChainOf(t, payment.Network, feePayer.address)
+	chain.Mint(t, authorization.From, authorization.Value)
+	now := time.Unix(authorization.ValidAfter.Int64(), 0)
+	facilitator, err := NewEVMFacilitatorWithBackend(t.Context(), chain.Client, evm.NewRawPrivateSigner(feePayer.key), feePayer.address,
+		Options{Clock: func() time.Time { return now }, Registry: chain.Registry})
+	require.NoError(t, err)
 	req := &types.PaymentRequirements{
-		Scheme:  string(types.EVM),
-		Network: Network,
-		Asset:   Token,
+		Scheme:            string(types.SchemeExact),
+		Network:           payment.Network,
+		MaxAmountRequired: authorization.Value.String(),
+		Resource:          Resource,
+		PayTo:             authorization.To.Hex(),
+		Asset:             chain.Token.Hex(),
 	}
 
-	res, err := facilitator.Verify(t.Context(), payload, req)
+	res, err := facilitator.Verify(t.Context(), &payment, req)
 	require.NoError(t, err)
-	jsonRes, err := json.MarshalIndent(res, "", "\t")
+	require.True(t, res.IsValid, res.InvalidReason)
+	require.Equal(t, authorization.From.Hex(), res.Payer)
+
+	// the payment is only valid within its window
+	now = time.Unix(authorization.ValidBefore.Int64(), 0)
+	res, err = facilitator.Verify(t.Context(), &payment, req)
 	require.NoError(t, err)
-	fmt.Println(string(jsonRes))
+	require.False(t, res.IsValid)
+	require.Equal(t, types.ErrInvalidExactEvmPayloadAuthorizationValidBefore.Error(), res.InvalidReason)
 }
 
 func TestEVMSettle(t *testing.T) {
-	facilitator, err := NewEVMFacilitator(Network, "", PrivateKey)
+	h := newEVMHarness(t, Options{})
+	ctx := t.Context()
+
+	payload := h.payment(t, 1000, h.payer.key, nil)
+	res, err := h.facilitator.Settle(ctx, payload, h.requirements("1000"))
+	require.NoError(t, err)
+	require.True(t, res.Success, res.ErrorReason)
+	require.Equal(t, h.payer.address.Hex(), res.Payer)
+	require.Equal(t, Network, res.Network)
+	require.Equal(t, "1000", res.Amount)
+	receipt := h.chain.Receipt(t, common.HexToHash(res.Transaction))
+	require.Equal(t, uint64(1), receipt.Status)
+	require.Equal(t, big.NewInt(9000), h.chain.BalanceOf(t, h.payer.address))
+	require.Equal(t, big.NewInt(1000), h.chain.BalanceOf(t, h.payTo))
+
+	// an authorization is transferred once
+	_, err = h.facilitator.Settle(ctx, payload, h.requirements("1000"))
+	require.Error(t, err)
+
+	require.Equal(t, big.NewInt(9000), h.chain.BalanceOf(t, h.payer.address))
+
+	// invalid payments are not settled
+	res, err = h.facilitator.Settle(ctx, h.payment(t, 1000, h.feePayer.key, nil), h.requirements("1000"))
 	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrInvalidExactEvmPayloadSignature.Error(), res.ErrorReason)
+	require.Equal(t, big.NewInt(9000), h.chain.BalanceOf(t, h.payer.address))
+}
+
+func TestEVMPaidResource(t *testing.T) {
+	h := newEVMHarness(t, Options{PaidResources: paid.Config{Enabled: true}})
+	ctx := t.Context()
 
-	privKey, err := hex.DecodeString("")
+	res, err := h.facilitator.Settle(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
 	require.NoError(t, err)
-	evmPayload, err := evm.NewEVMPayload(Network, Token,
-		"", "", "10000", evm.NewRawPrivateSigner(privKey))
+	require.True(t, res.Success, res.ErrorReason)
+
+	// a concurrent settlement does not pay for the resource while the first one is pending
+	pending, err := h.facilitator.Settle(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
 	require.NoError(t, err)
-	evmPayloadJson, err := json.Marshal(evmPayload)
+	require.False(t, pending.Success)
+	require.Equal(t, types.ErrResourceAlreadyPaid.Error(), pending.ErrorReason)
+	verified, err := h.facilitator.Verify(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
 	require.NoError(t, err)
+	require.True(t, verified.IsValid, verified.InvalidReason)
 
-	payload := &types.PaymentPayload{
-		X402Version: int(types.X402VersionV1),
-		Scheme:      string(types.EVM),
-		Network:     Network,
-		Payload:     evmPayloadJson,
-	}
+	// a one-time resource is paid for once its settlement is mined
+	h.chain.Receipt(t, common.HexToHash(res.Transaction))
+	require.Eventually(t, func() bool {
+		verified, err = h.facilitator.Verify(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
+		return err == nil && !verified.IsValid
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, types.ErrResourceAlreadyPaid.Error(), verified.InvalidReason)
+	require.Equal(t, res.Transaction, verified.Receipt.TxHash)
 
-	req := &types.PaymentRequirements{
-		Scheme:  string(types.EVM),
-		Network: Network,
-		Asset:   Token,
-	}
+	res, err = h.facilitator.Settle(ctx, h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
+	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrResourceAlreadyPaid.Error(), res.ErrorReason)
+	require.Equal(t, big.NewInt(9000), h.chain.BalanceOf(t, h.payer.address))
+}
+
+func TestEVMPolicy(t *testing.T) {
+	payer, err := crypto.HexToECDSA(PayerKey)
+	require.NoError(t, err)
+	denied := filepath.Join(t.TempDir(), "deny.txt")
+	require.NoError(t, os.WriteFile(denied, []byte(crypto.PubkeyToAddress(payer.PublicKey).Hex()+"\n"), 0o600))
+	h := newEVMHarness(t, Options{Policy: policy.Config{Enabled: true, DenyPayers: denied}})
+
+	res, err := h.facilitator.Verify(t.Context(), h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
+	require.NoError(t, err)
+	require.False(t, res.IsValid)
+	require.Equal(t, types.ErrPolicyViolation.Error(), res.InvalidReason)
+
+	settled, err := h.facilitator.Settle(t.Context(), h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
+	require.NoError(t, err)
+	require.False(t, settled.Success)
+	require.Equal(t, types.ErrPolicyViolation.Error(), settled.ErrorReason)
+	require.Equal(t, big.NewInt(10000), h.chain.BalanceOf(t, h.payer.address))
+}
+
+func TestEVMFacilitatorWithBackend(t *testing.T) {
+	h := newEVMHarness(t, Options{})
+	require.Equal(t, Network, h.facilitator.network)
+	require.Equal(t, h.feePayer.address.Hex(), h.facilitator.Supported()[0].FeePayer)
+
+	// the fee payer address is the one of the signer
+	_, err := NewEVMFacilitatorWithBackend(t.Context(), h.chain.Client, evm.NewRawPrivateSigner(h.feePayer.key), h.payTo, Options{Registry: h.chain.Registry})
+	require.Error(t, err)
+
+	// the network is resolved by the registry of the options, the default one not knowing the chain
+	_, err = NewEVMFacilitatorWithBackend(t.Context(), h.chain.Client, evm.NewRawPrivateSigner(h.feePayer.key), h.feePayer.address, Options{})
+	require.ErrorContains(t, err, "unsupported chain ID")
+
+	// the setup requests are bounded by the context
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = NewEVMFacilitatorWithBackend(ctx, h.chain.Client, evm.NewRawPrivateSigner(h.feePayer.key), h.feePayer.address, Options{Registry: h.chain.Registry})
+	require.ErrorIs(t, err, context.Canceled)
 
-	res, err := facilitator.Settle(t.Context(), payload, req)
+	// deadlines are checked against the clock
+	late := time.Now().Add(time.Hour)
+	h = newEVMHarness(t, Options{Clock: func() time.Time { return late }})
+	res, err := h.facilitator.Verify(t.Context(), h.payment(t, 1000, h.payer.key, nil), h.requirements("1000"))
 	require.NoError(t, err)
+	require.False(t, res.IsValid)
+	require.Equal(t, types.ErrInvalidExactEvmPayloadAuthorizationValidBefore.Error(), res.InvalidReason)
 
-	jsonRes, err := json.MarshalIndent(res, "", "\t")
+	// the calls to the backend are traced
+	exporter := tracetest.NewInMemoryExporter()
+	provider := telemetry.NewTracerProvider(telemetry.Config{}, sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	_, err = NewEVMFacilitatorWithBackend(t.Context(), h.chain.Client, evm.NewRawPrivateSigner(h.feePayer.key), h.feePayer.address, Options{Registry: h.chain.Registry})
 	require.NoError(t, err)
-	fmt.Println(string(jsonRes))
+	require.NotEmpty(t, exporter.GetSpans())
+	require.Equal(t, "eth_chainId", exporter.GetSpans()[0].Name)
 }
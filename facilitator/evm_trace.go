@@ -0,0 +1,136 @@
+package facilitator
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rabbitprincess/x402-facilitator/telemetry"
+)
+
+var _ Backend = (*tracedClient)(nil)
+
+// tracedClient wraps a Backend and records a client span for every RPC call.
+type tracedClient struct {
+	Backend
+}
+
+func newTracedClient(backend Backend) *tracedClient {
+	return &tracedClient{Backend: backend}
+}
+
+func (t *tracedClient) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(telemetry.AttrRPCMethod.String(method)),
+	)
+}
+
+func (t *tracedClient) ChainID(ctx context.Context) (*big.Int, error) {
+	ctx, span := t.start(ctx, "eth_chainId")
+	defer span.End()
+	id, err := t.Backend.ChainID(ctx)
+	telemetry.RecordError(span, err)
+	return id, err
+}
+
+func (t *tracedClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	ctx, span := t.start(ctx, "eth_getCode")
+	defer span.End()
+	code, err := t.Backend.CodeAt(ctx, contract, blockNumber)
+	telemetry.RecordError(span, err)
+	return code, err
+}
+
+func (t *tracedClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	ctx, span := t.start(ctx, "eth_call")
+	defer span.End()
+	res, err := t.Backend.CallContract(ctx, call, blockNumber)
+	telemetry.RecordError(span, err)
+	return res, err
+}
+
+func (t *tracedClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	ctx, span := t.start(ctx, "eth_estimateGas")
+	defer span.End()
+	gas, err := t.Backend.EstimateGas(ctx, call)
+	telemetry.RecordError(span, err)
+	return gas, err
+}
+
+func (t *tracedClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	ctx, span := t.start(ctx, "eth_gasPrice")
+	defer span.End()
+	price, err := t.Backend.SuggestGasPrice(ctx)
+	telemetry.RecordError(span, err)
+	return price, err
+}
+
+func (t *tracedClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	ctx, span := t.start(ctx, "eth_maxPriorityFeePerGas")
+	defer span.End()
+	tip, err := t.Backend.SuggestGasTipCap(ctx)
+	telemetry.RecordError(span, err)
+	return tip, err
+}
+
+func (t *tracedClient) SendTransaction(ctx context.Context, tx *ethTypes.Transaction) error {
+	ctx, span := t.start(ctx, "eth_sendRawTransaction")
+	defer span.End()
+	span.SetAttributes(telemetry.AttrTxHash.String(tx.Hash().Hex()))
+	err := t.Backend.SendTransaction(ctx, tx)
+	telemetry.RecordError(span, err)
+	return err
+}
+
+func (t *tracedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	ctx, span := t.start(ctx, "eth_getBlockByNumber")
+	defer span.End()
+	header, err := t.Backend.HeaderByNumber(ctx, number)
+	telemetry.RecordError(span, err)
+	return header, err
+}
+
+func (t *tracedClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	ctx, span := t.start(ctx, "eth_getCode")
+	defer span.End()
+	code, err := t.Backend.PendingCodeAt(ctx, account)
+	telemetry.RecordError(span, err)
+	return code, err
+}
+
+func (t *tracedClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	ctx, span := t.start(ctx, "eth_getTransactionCount")
+	defer span.End()
+	nonce, err := t.Backend.PendingNonceAt(ctx, account)
+	telemetry.RecordError(span, err)
+	return nonce, err
+}
+
+func (t *tracedClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	ctx, span := t.start(ctx, "eth_getLogs")
+	defer span.End()
+	logs, err := t.Backend.FilterLogs(ctx, query)
+	telemetry.RecordError(span, err)
+	return logs, err
+}
+
+func (t *tracedClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- ethTypes.Log) (ethereum.Subscription, error) {
+	ctx, span := t.start(ctx, "eth_subscribe")
+	defer span.End()
+	sub, err := t.Backend.SubscribeFilterLogs(ctx, query, ch)
+	telemetry.RecordError(span, err)
+	return sub, err
+}
+
+func (t *tracedClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error) {
+	ctx, span := t.start(ctx, "eth_getTransactionReceipt")
+	defer span.End()
+	receipt, err := t.Backend.TransactionReceipt(ctx, txHash)
+	telemetry.RecordError(span, err)
+	return receipt, err
+}
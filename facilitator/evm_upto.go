@@ -0,0 +1,268 @@
+package facilitator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/erc20"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/permit2"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// upto verification steps:
+//   - ✅ verify payload format
+//   - ✅ verify usdc address is correct for the chain and permitted
+//   - ✅ verify Permit2 signature of the owner
+//   - ✅ verify permit is granted to the facilitator
+//   - ✅ verify witness recipient and resource are paymentRequirements.payTo and resource
+//   - ✅ verify deadline
+//   - ✅ verify nonce is unused
+//   - ✅ verify amount in permit covers paymentRequirements.maxAmountRequired
+//   - ✅ verify client has enough funds and Permit2 allowance to cover paymentRequirements.maxAmountRequired
+//   - ✅ check max amount is above the threshold configured for covering gas
+//   - ✅ verify one-time resource is not already paid for
+//   - ✅ verify payer and payee are allowed by the policy
+func (t *EVMFacilitator) verifyUpto(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	// the resource server settles once the resource is served, within its timeout
+	return t.checkUpto(ctx, payload, req, time.Duration(req.MaxTimeoutSeconds)*time.Second)
+}
+
+// checkUpto verifies an upto payment whose permit is valid for at least validity.
+func (t *EVMFacilitator) checkUpto(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements, validity time.Duration) (*types.PaymentVerifyResponse, error) {
+	steps := &stepTracer{ctx: ctx}
+	defer steps.end()
+
+	// Step 1: Payload format
+	steps.start("payload_format")
+	var uptoPayload evm.UptoPayload
+	if err := json.Unmarshal(payload.Payload, &uptoPayload); err != nil || !validPermit(uptoPayload.Permit) {
+		return &types.PaymentVerifyResponse{
+			IsValid:       false,
+			InvalidReason: types.ErrInvalidPayload.Error(),
+		}, nil
+	}
+	permit := uptoPayload.Permit
+	invalid := func(reason error) (*types.PaymentVerifyResponse, error) {
+		return &types.PaymentVerifyResponse{
+			IsValid:       false,
+			InvalidReason: reason.Error(),
+			Payer:         permit.Owner.String(),
+		}, nil
+	}
+
+	// Step 2: Payment requirements
+	steps.start("requirements")
+	maxAmount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok || maxAmount.Sign() <= 0 || !common.IsHexAddress(req.PayTo) {
+		return invalid(types.ErrInvalidPaymentRequirements)
+	}
+	if t.minAmount != nil && maxAmount.Cmp(t.minAmount) < 0 {
+		return invalid(types.ErrInvalidPaymentRequirements)
+	}
+
+	// Step 3: Network info and Contract info
+	steps.start("network")
+	domainConfig, reason := t.domainOf(ctx, payload, req)
+	if reason != nil {
+		return invalid(reason)
+	}
+	if permit.Token != domainConfig.VerifyingContract {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitToken)
+	}
+
+	// Step 4: Verify Permit2 signature of the owner (EIP-712)
+	steps.start("signature")
+	sig, err := evm.ParseSignature(uptoPayload.Signature)
+	if err != nil {
+		return invalid(types.ErrInvalidUptoEvmPayloadSignature)
+	}
+	signer, err := evm.RecoverAddress(evm.HashPermit(permit, t.networkID), sig)
+	if err != nil || signer != permit.Owner {
+		return invalid(types.ErrInvalidUptoEvmPayloadSignature)
+	}
+
+	// Step 5: Permit is granted to the facilitator
+	if permit.Spender != t.address {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitSpender)
+	}
+
+	// Step 6: The signed witness pays payTo for the resource, the requirements are not trusted
+	if permit.Witness.PayTo != common.HexToAddress(req.PayTo) {
+		return invalid(types.ErrInvalidUptoEvmPayloadRecipientMismatch)
+	}
+	if permit.Witness.Resource != req.Resource {
+		return invalid(types.ErrInvalidUptoEvmPayloadResourceMismatch)
+	}
+
+	// Step 7: Deadline check, leaving time to settle
+	if permit.Deadline.Cmp(big.NewInt(t.now().Add(validity).Unix())) <= 0 {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitDeadline)
+	}
+
+	// Step 8: Nonce is unused, Permit2 nonces being bits of the owner's nonce bitmap
+	nonceCtx := steps.start("nonce")
+	contract, err := permit2.NewPermit2Caller(evm.Permit2Address, t.client)
+	if err != nil {
+		return nil, fmt.Errorf("contract bind failed: %w", err)
+	}
+	bitmap, err := contract.NonceBitmap(&bind.CallOpts{Context: nonceCtx}, permit.Owner, new(big.Int).Rsh(permit.Nonce, 8))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permit nonce: %w", err)
+	}
+	bit := new(big.Int).And(permit.Nonce, big.NewInt(0xff))
+	if bitmap.Bit(int(bit.Int64())) != 0 {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitNonce)
+	}
+
+	// Step 9: Check amount in permit covers the maximum amount
+	steps.start("value")
+	if permit.Amount.Cmp(maxAmount) < 0 {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitValue)
+	}
+
+	// Step 10: Check ERC20 balance and the allowance of Permit2
+	balanceCtx := steps.start("balance")
+	token, err := erc20.NewErc20Caller(permit.Token, t.client)
+	if err != nil {
+		return nil, fmt.Errorf("contract bind failed: %w", err)
+	}
+	balance, err := token.BalanceOf(&bind.CallOpts{Context: balanceCtx}, permit.Owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	if balance.Cmp(maxAmount) < 0 {
+		return invalid(types.ErrInsufficientFunds)
+	}
+	allowance, err := token.Allowance(&bind.CallOpts{Context: balanceCtx}, permit.Owner, evm.Permit2Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowance: %w", err)
+	}
+	if allowance.Cmp(maxAmount) < 0 {
+		return invalid(types.ErrInvalidUptoEvmPayloadPermitAllowance)
+	}
+
+	// Step 11: Check if a one-time resource is already paid
+	if t.resources != nil {
+		resourceCtx := steps.start("resource")
+		receipt, err := t.resources.Paid(resourceCtx, req.Resource, permit.Owner.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check paid resources: %w", err)
+		}
+		if receipt != nil {
+			return &types.PaymentVerifyResponse{
+				IsValid:       false,
+				InvalidReason: types.ErrResourceAlreadyPaid.Error(),
+				Payer:         permit.Owner.String(),
+				Receipt:       receipt,
+			}, nil
+		}
+	}
+
+	// Step 12: Check the policy allows the payer and payee
+	if t.policy != nil {
+		allowed, err := t.allowed(steps.start("policy"), permit.Owner, permit.Witness.PayTo)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return invalid(types.ErrPolicyViolation)
+		}
+	}
+
+	// ✅ All checks passed
+	return &types.PaymentVerifyResponse{
+		IsValid: true,
+		Payer:   permit.Owner.String(),
+	}, nil
+}
+
+// settleUpto transfers the settled amount from the owner to the recipient signed in the permit,
+// with a single Permit2 call checking the signature and spending the nonce.
+func (t *EVMFacilitator) settleUpto(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	// the payment is verified again, it may have expired or been spent since its verification
+	verified, err := t.checkUpto(ctx, payload, req, validityBuffer)
+	if err != nil {
+		return nil, err
+	}
+	if !verified.IsValid {
+		return &types.PaymentSettleResponse{
+			Success:     false,
+			ErrorReason: verified.InvalidReason,
+			Payer:       verified.Payer,
+			Network:     req.Network,
+		}, nil
+	}
+
+	var uptoPayload evm.UptoPayload
+	if err := json.Unmarshal(payload.Payload, &uptoPayload); err != nil {
+		return nil, err
+	}
+	permit := uptoPayload.Permit
+
+	// the settled amount is at most the permitted and the required maximum
+	amount, ok := new(big.Int).SetString(req.SettleAmount(), 10)
+	maxAmount, _ := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok || amount.Sign() <= 0 || amount.Cmp(maxAmount) > 0 || amount.Cmp(permit.Amount) > 0 ||
+		(t.minAmount != nil && amount.Cmp(t.minAmount) < 0) {
+		return &types.PaymentSettleResponse{
+			Success:     false,
+			ErrorReason: types.ErrInvalidUptoEvmSettleAmount.Error(),
+			Payer:       permit.Owner.String(),
+			Network:     req.Network,
+		}, nil
+	}
+
+	contract, err := permit2.NewPermit2Transactor(evm.Permit2Address, t.client)
+	if err != nil {
+		return nil, fmt.Errorf("contract bind failed: %w", err)
+	}
+	clientSig, err := evm.ParseSignature(uptoPayload.Signature) // client signature
+	if err != nil {
+		return nil, err
+	}
+	payer := permit.Owner.String()
+	if res, err := t.reserveResource(ctx, req, payer); res != nil || err != nil {
+		return res, err
+	}
+	tx, err := contract.PermitWitnessTransferFrom(
+		&bind.TransactOpts{
+			Context: ctx,
+			Signer:  evm.ToGethSigner(t.signer, t.networkID), // facilitator signature
+			From:    t.address,
+		},
+		permit2.ISignatureTransferPermitTransferFrom{
+			Permitted: permit2.ISignatureTransferTokenPermissions{Token: permit.Token, Amount: permit.Amount},
+			Nonce:     permit.Nonce,
+			Deadline:  permit.Deadline,
+		},
+		permit2.ISignatureTransferSignatureTransferDetails{To: permit.Witness.PayTo, RequestedAmount: amount},
+		permit.Owner,
+		[32]byte(permit.Witness.ToMessageHash()),
+		evm.WitnessTypeString,
+		clientSig,
+	)
+	if err != nil {
+		t.releaseResource(ctx, req.Resource, payer)
+		return nil, fmt.Errorf("failed to transfer with permit %w", err)
+	}
+	t.recordResource(ctx, req, payer, tx.Hash())
+
+	return &types.PaymentSettleResponse{
+		Success:     true,
+		Payer:       payer,
+		Transaction: tx.Hash().Hex(),
+		Network:     req.Network,
+		Amount:      amount.String(),
+	}, nil
+}
+
+func validPermit(permit *evm.Permit) bool {
+	return permit != nil && permit.Amount != nil && permit.Nonce != nil && permit.Deadline != nil
+}
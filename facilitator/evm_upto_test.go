@@ -0,0 +1,204 @@
+package facilitator
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/paid"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// approvePermit2 lets Permit2 transfer up to amount of the payer's tokens
+func (h *evmHarness) approvePermit2(t *testing.T, amount int64) {
+	key, err := crypto.ToECDSA(h.payer.key)
+	require.NoError(t, err)
+	h.chain.Approve(t, key, evm.Permit2Address, big.NewInt(amount))
+}
+
+// uptoPayment returns a permit of up to maxValue from the payer to payTo for Resource, granted to the fee payer
+// and signed by signer, edited by edit before signing
+func (h *evmHarness) uptoPayment(t *testing.T, maxValue int64, signer []byte, edit func(*evm.Permit)) *types.PaymentPayload {
+	nonce := evm.GenerateEIP3009Nonce()
+	permit := &evm.Permit{
+		Owner:    h.payer.address,
+		Token:    h.chain.Token,
+		Amount:   big.NewInt(maxValue),
+		Spender:  h.feePayer.address,
+		Nonce:    new(big.Int).SetBytes(nonce[:]),
+		Deadline: big.NewInt(time.Now().Add(time.Hour).Unix()),
+		Witness:  evm.Witness{PayTo: h.payTo, Resource: Resource},
+	}
+	if edit != nil {
+		edit(permit)
+	}
+	signature, err := evm.SignPermit(permit, h.chain.ChainID, evm.NewRawPrivateSigner(signer))
+	require.NoError(t, err)
+	payload, err := json.Marshal(&evm.UptoPayload{Signature: signature, Permit: permit})
+	require.NoError(t, err)
+	return &types.PaymentPayload{
+		X402Version: int(types.X402VersionV1),
+		Scheme:      string(types.SchemeUpto),
+		Network:     Network,
+		Payload:     payload,
+	}
+}
+
+func (h *evmHarness) uptoRequirements(maxAmount, amount string) *types.PaymentRequirements {
+	req := h.requirements(maxAmount)
+	req.Scheme = string(types.SchemeUpto)
+	req.Amount = amount
+	return req
+}
+
+func TestEVMUptoVerify(t *testing.T) {
+	h := newEVMHarness(t, Options{})
+	ctx := t.Context()
+
+	// Permit2 transfers need the payer's allowance
+	res, err := h.facilitator.Verify(ctx, h.uptoPayment(t, 1000, h.payer.key, nil), h.uptoRequirements("1000", ""))
+	require.NoError(t, err)
+	require.False(t, res.IsValid)
+	require.Equal(t, types.ErrInvalidUptoEvmPayloadPermitAllowance.Error(), res.InvalidReason)
+
+	h.approvePermit2(t, 10000)
+	res, err = h.facilitator.Verify(ctx, h.uptoPayment(t, 1000, h.payer.key, nil), h.uptoRequirements("1000", ""))
+	require.NoError(t, err)
+	require.True(t, res.IsValid, res.InvalidReason)
+	require.Equal(t, h.payer.address.Hex(), res.Payer)
+
+	for _, tc := range []struct {
+		name    string
+		payload func() *types.PaymentPayload
+		req     func(req *types.PaymentRequirements)
+		reason  error
+	}{
+		{
+			name:    "signed by another account",
+			payload: func() *types.PaymentPayload { return h.uptoPayment(t, 1000, h.feePayer.key, nil) },
+			reason:  types.ErrInvalidUptoEvmPayloadSignature,
+		},
+		{
+			name: "other token",
+			payload: func() *types.PaymentPayload {
+				return h.uptoPayment(t, 1000, h.payer.key, func(p *evm.Permit) { p.Token = h.payTo })
+			},
+			reason: types.ErrInvalidUptoEvmPayloadPermitToken,
+		},
+		{
+			name: "granted to another spender",
+			payload: func() *types.PaymentPayload {
+				return h.uptoPayment(t, 1000, h.payer.key, func(p *evm.Permit) { p.Spender = h.payTo })
+			},
+			reason: types.ErrInvalidUptoEvmPayloadPermitSpender,
+		},
+		{
+			name:   "requirements paying another recipient",
+			req:    func(req *types.PaymentRequirements) { req.PayTo = h.feePayer.address.Hex() },
+			reason: types.ErrInvalidUptoEvmPayloadRecipientMismatch,
+		},
+		{
+			name:   "requirements of another resource",
+			req:    func(req *types.PaymentRequirements) { req.Resource = "https://example.com/other" },
+			reason: types.ErrInvalidUptoEvmPayloadResourceMismatch,
+		},
+		{
+			name: "deadline before the settlement timeout",
+			payload: func() *types.PaymentPayload {
+				return h.uptoPayment(t, 1000, h.payer.key, func(p *evm.Permit) {
+					p.Deadline = big.NewInt(time.Now().Add(time.Minute).Unix())
+				})
+			},
+			req:    func(req *types.PaymentRequirements) { req.MaxTimeoutSeconds = 300 },
+			reason: types.ErrInvalidUptoEvmPayloadPermitDeadline,
+		},
+		{
+			name:    "amount under the required amount",
+			payload: func() *types.PaymentPayload { return h.uptoPayment(t, 999, h.payer.key, nil) },
+			reason:  types.ErrInvalidUptoEvmPayloadPermitValue,
+		},
+		{
+			name:    "amount over the balance",
+			payload: func() *types.PaymentPayload { return h.uptoPayment(t, 20000, h.payer.key, nil) },
+			req:     func(req *types.PaymentRequirements) { req.MaxAmountRequired = "20000" },
+			reason:  types.ErrInsufficientFunds,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := h.uptoPayment(t, 1000, h.payer.key, nil)
+			if tc.payload != nil {
+				payload = tc.payload()
+			}
+			req := h.uptoRequirements("1000", "")
+			if tc.req != nil {
+				tc.req(req)
+			}
+			res, err := h.facilitator.Verify(ctx, payload, req)
+			require.NoError(t, err)
+			require.False(t, res.IsValid)
+			require.Equal(t, tc.reason.Error(), res.InvalidReason)
+		})
+	}
+}
+
+func TestEVMUptoSettle(t *testing.T) {
+	h := newEVMHarness(t, Options{})
+	h.approvePermit2(t, 10000)
+	ctx := t.Context()
+
+	// the resource server settles the amount used, at most the permitted one
+	payload := h.uptoPayment(t, 1000, h.payer.key, nil)
+	res, err := h.facilitator.Settle(ctx, payload, h.uptoRequirements("1000", "1001"))
+	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrInvalidUptoEvmSettleAmount.Error(), res.ErrorReason)
+
+	// the requirements cannot redirect the payment
+	req := h.uptoRequirements("1000", "420")
+	req.PayTo = h.feePayer.address.Hex()
+	res, err = h.facilitator.Settle(ctx, payload, req)
+	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrInvalidUptoEvmPayloadRecipientMismatch.Error(), res.ErrorReason)
+
+	res, err = h.facilitator.Settle(ctx, payload, h.uptoRequirements("1000", "420"))
+	require.NoError(t, err)
+	require.True(t, res.Success, res.ErrorReason)
+	require.Equal(t, h.payer.address.Hex(), res.Payer)
+	require.Equal(t, "420", res.Amount)
+	receipt := h.chain.Receipt(t, common.HexToHash(res.Transaction))
+	require.Equal(t, uint64(1), receipt.Status)
+	require.Equal(t, big.NewInt(9580), h.chain.BalanceOf(t, h.payer.address))
+	require.Equal(t, big.NewInt(420), h.chain.BalanceOf(t, h.payTo))
+
+	// a permit is settled once, its nonce being spent with the transfer
+	res, err = h.facilitator.Settle(ctx, payload, h.uptoRequirements("1000", "420"))
+	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrInvalidUptoEvmPayloadPermitNonce.Error(), res.ErrorReason)
+	require.Equal(t, big.NewInt(9580), h.chain.BalanceOf(t, h.payer.address))
+}
+
+func TestEVMUptoPaidResource(t *testing.T) {
+	h := newEVMHarness(t, Options{PaidResources: paid.Config{Enabled: true}})
+	h.approvePermit2(t, 10000)
+	ctx := t.Context()
+
+	res, err := h.facilitator.Settle(ctx, h.uptoPayment(t, 1000, h.payer.key, nil), h.uptoRequirements("1000", ""))
+	require.NoError(t, err)
+	require.True(t, res.Success, res.ErrorReason)
+	h.chain.Receipt(t, common.HexToHash(res.Transaction))
+
+	// a one-time resource is paid for once
+	res, err = h.facilitator.Settle(ctx, h.uptoPayment(t, 1000, h.payer.key, nil), h.uptoRequirements("1000", ""))
+	require.NoError(t, err)
+	require.False(t, res.Success)
+	require.Equal(t, types.ErrResourceAlreadyPaid.Error(), res.ErrorReason)
+	require.Equal(t, big.NewInt(9000), h.chain.BalanceOf(t, h.payer.address))
+}
@@ -13,10 +13,21 @@ type Facilitator interface {
 	Supported() []*types.SupportedKind
 }
 
-func NewFacilitator(scheme types.Scheme, network, rpcUrl string, privateKeyHex string) (Facilitator, error) {
-	switch scheme {
+// HealthChecker is an optional interface for facilitators that can probe their
+// upstream dependencies (RPC node, signer, chain head) for readiness checks.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) []*types.NetworkHealth
+}
+
+func NewFacilitator(ctx context.Context, family types.Family, network string, rpcUrls []string, privateKeyHex string, opts Options) (Facilitator, error) {
+	var rpcUrl string
+	if len(rpcUrls) > 0 {
+		rpcUrl = rpcUrls[0]
+	}
+
+	switch family {
 	case types.EVM:
-		return NewEVMFacilitator(network, rpcUrl, privateKeyHex)
+		return NewEVMFacilitator(ctx, network, rpcUrls, privateKeyHex, opts)
 	case types.Solana:
 		return NewSolanaFacilitator(network, rpcUrl, privateKeyHex)
 	case types.Sui:
@@ -24,6 +35,6 @@ func NewFacilitator(scheme types.Scheme, network, rpcUrl string, privateKeyHex s
 	case types.Tron:
 		return NewTronFacilitator(network, rpcUrl, privateKeyHex)
 	default:
-		return nil, fmt.Errorf("unsupporsed scheme: %s", scheme)
+		return nil, fmt.Errorf("unsupported network family: %s", family)
 	}
 }
@@ -0,0 +1,32 @@
+package facilitator
+
+import (
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/paid"
+	"github.com/rabbitprincess/x402-facilitator/policy"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/rpcpool"
+)
+
+// Options configures optional facilitator behaviour. The zero value uses the defaults.
+type Options struct {
+	// RPC configures failover, hedging and circuit breaking across the rpc urls
+	RPC rpcpool.Config
+	// PaidResources rejects payers paying again for a one-time resource they already paid for
+	PaidResources paid.Config
+	// PaidResourceStore keeps the receipts of paid resources, in memory if nil
+	PaidResourceStore paid.Store
+	// MinAmountRequired is the minimum amount in atomic units a payment must be worth to cover the gas of its settlement, none if empty
+	MinAmountRequired string
+	// DomainTTL is how long the on-chain EIP-712 domains of tokens are cached, evm.DefaultDomainTTL if zero
+	DomainTTL time.Duration
+	// Policy rejects payments of payers or to payees not allowed by its lists or sanctioned
+	Policy policy.Config
+	// Screener screens payers and payees against sanctions when Policy is enabled, in addition to its sanctions list
+	Screener policy.Screener
+	// Registry resolves the network of the chain and its tokens, evm.DefaultRegistry if nil
+	Registry *evm.Registry
+	// Clock returns the current time deadlines are checked against, time.Now if nil
+	Clock func() time.Time
+}
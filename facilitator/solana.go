@@ -12,7 +12,7 @@ import (
 )
 
 type SolanaFacilitator struct {
-	scheme   types.Scheme
+	family   types.Family
 	client   *client.Client
 	feePayer solTypes.Account
 }
@@ -31,7 +31,7 @@ func NewSolanaFacilitator(network string, url string, privateKeyHex string) (*So
 	}
 
 	return &SolanaFacilitator{
-		scheme:   types.Solana,
+		family:   types.Solana,
 		client:   client,
 		feePayer: feePayer,
 	}, nil
@@ -48,8 +48,9 @@ func (t *SolanaFacilitator) Settle(ctx context.Context, payload *types.PaymentPa
 func (t *SolanaFacilitator) Supported() []*types.SupportedKind {
 	return []*types.SupportedKind{
 		{
-			Scheme:  string(types.Solana),
-			Network: string(types.Solana),
+			X402Version: int(types.X402VersionV1),
+			Scheme:      string(types.SchemeExact),
+			Network:     string(types.Solana),
 		},
 	}
 }
@@ -24,8 +24,9 @@ func (t *SuiFacilitator) Settle(ctx context.Context, payload *types.PaymentPaylo
 func (t *SuiFacilitator) Supported() []*types.SupportedKind {
 	return []*types.SupportedKind{
 		{
-			Scheme:  string(types.Sui),
-			Network: string(types.Sui),
+			X402Version: int(types.X402VersionV1),
+			Scheme:      string(types.SchemeExact),
+			Network:     string(types.Sui),
 		},
 	}
 }
@@ -24,8 +24,9 @@ func (t *TronFacilitator) Settle(ctx context.Context, payload *types.PaymentPayl
 func (t *TronFacilitator) Supported() []*types.SupportedKind {
 	return []*types.SupportedKind{
 		{
-			Scheme:  string(types.Tron),
-			Network: string(types.Tron),
+			X402Version: int(types.X402VersionV1),
+			Scheme:      string(types.SchemeExact),
+			Network:     string(types.Tron),
 		},
 	}
 }
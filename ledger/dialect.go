@@ -0,0 +1,45 @@
+package ledger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect adapts queries to a SQL database. Queries are written with "?" placeholders.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// dialectOf returns the dialect of a database/sql driver name.
+func dialectOf(driver string) (Dialect, error) {
+	switch driver {
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	case "postgres", "pgx":
+		return Postgres, nil
+	default:
+		return "", fmt.Errorf("unsupported ledger driver %q", driver)
+	}
+}
+
+// rebind rewrites "?" placeholders into the dialect's placeholders.
+func (d Dialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
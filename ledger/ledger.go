@@ -0,0 +1,393 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var (
+	// ErrNotFound is returned when a payment is not in the ledger.
+	ErrNotFound = errors.New("payment not found")
+	// ErrInvalidCursor is returned by List for cursors it did not issue.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Config configures the payment ledger.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// database/sql driver name, "sqlite" or "postgres"/"pgx". The driver must be registered by the binary.
+	Driver string `mapstructure:"driver"`
+	// Data source name passed to the driver
+	DSN string `mapstructure:"dsn"`
+}
+
+// Ledger records every verification and settlement handled by the facilitator.
+type Ledger struct {
+	db      *sql.DB
+	dialect Dialect
+	now     func() time.Time
+}
+
+// Open connects to the database configured by config and applies pending migrations.
+func Open(ctx context.Context, config Config) (*Ledger, error) {
+	dialect, err := dialectOf(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == SQLite {
+		// SQLite allows a single writer, serialize access instead of failing with SQLITE_BUSY
+		db.SetMaxOpenConns(1)
+	}
+
+	l := New(db, dialect)
+	if err := l.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate ledger: %w", err)
+	}
+	return l, nil
+}
+
+// New creates a ledger on an open database. Migrate must be called before use.
+func New(db *sql.DB, dialect Dialect) *Ledger {
+	return &Ledger{
+		db:      db,
+		dialect: dialect,
+		now:     time.Now,
+	}
+}
+
+func (l *Ledger) Close() error {
+	return l.db.Close()
+}
+
+// Entry identifies the payment and the request of a ledger update.
+type Entry struct {
+	Payload      *types.PaymentPayload
+	Requirements *types.PaymentRequirements
+	RequestID    string
+	TenantID     string
+}
+
+// PaymentID returns the ledger identifier of a payment payload.
+// Verifications and settlements of the same payload share the identifier.
+func PaymentID(payload *types.PaymentPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RecordVerify records the outcome of a verification.
+func (l *Ledger) RecordVerify(ctx context.Context, entry Entry, res *types.PaymentVerifyResponse, verifyErr error) error {
+	event := types.PaymentEvent{Status: types.PaymentStatusInvalid}
+	payer := ""
+	switch {
+	case verifyErr != nil:
+		event.Reason = verifyErr.Error()
+	case res.IsValid:
+		event.Status = types.PaymentStatusVerified
+		payer = res.Payer
+	default:
+		event.Reason = res.InvalidReason
+		payer = res.Payer
+	}
+	return l.record(ctx, entry, payer, event)
+}
+
+// RecordSettle records the outcome of a settlement.
+func (l *Ledger) RecordSettle(ctx context.Context, entry Entry, res *types.PaymentSettleResponse, settleErr error) error {
+	event := types.PaymentEvent{Status: types.PaymentStatusFailed}
+	switch {
+	case settleErr != nil:
+		event.Reason = settleErr.Error()
+	case res.Success:
+		event.Status = types.PaymentStatusSettled
+		event.TxHash = res.Transaction
+	default:
+		event.Reason = res.ErrorReason
+		event.TxHash = res.Transaction
+	}
+	return l.record(ctx, entry, "", event)
+}
+
+// record appends event to the payment's history and applies its status transition.
+// Settled payments stay settled, later attempts are only kept as events.
+func (l *Ledger) record(ctx context.Context, entry Entry, payer string, event types.PaymentEvent) error {
+	id, err := PaymentID(entry.Payload)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(entry.Payload)
+	if err != nil {
+		return err
+	}
+	requirements, err := json.Marshal(entry.Requirements)
+	if err != nil {
+		return err
+	}
+	if payer == "" {
+		payer = payerOf(entry.Payload)
+	}
+	now := l.now().UnixMicro()
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// concurrent first records of a payment insert it once, the tenant of the first one owning it
+	req := entry.Requirements
+	if _, err := tx.ExecContext(ctx, l.dialect.rebind(`INSERT INTO payments (
+		id, status, scheme, network, payer, pay_to, asset, amount, resource,
+		invalid_reason, tx_hash, error, request_id, tenant_id, payload, requirements, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '', '', '', ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`),
+		id, event.Status, entry.Payload.Scheme, entry.Payload.Network, payer, req.PayTo, req.Asset, req.SettleAmount(), req.Resource,
+		entry.RequestID, entry.TenantID, string(payload), string(requirements), now, now); err != nil {
+		return err
+	}
+
+	// the update locks the payment row until commit, serializing the numbering of its events
+	var seq int
+	if err := tx.QueryRowContext(ctx, l.dialect.rebind(`UPDATE payments SET
+		status = CASE WHEN status = ? THEN status ELSE ? END,
+		payer = CASE WHEN ? <> '' THEN ? ELSE payer END,
+		request_id = ?, updated_at = ?, event_count = event_count + 1
+		WHERE id = ? RETURNING event_count`),
+		types.PaymentStatusSettled, event.Status, payer, payer, entry.RequestID, now, id).Scan(&seq); err != nil {
+		return err
+	}
+
+	// the last attempt's outcome, a successful settlement keeps its tx hash
+	switch event.Status {
+	case types.PaymentStatusInvalid:
+		_, err = tx.ExecContext(ctx, l.dialect.rebind(`UPDATE payments SET invalid_reason = ? WHERE id = ?`), event.Reason, id)
+	case types.PaymentStatusSettled:
+		// the settled amount of upto payments is only known when settling
+		_, err = tx.ExecContext(ctx, l.dialect.rebind(`UPDATE payments SET tx_hash = ?, amount = ?, error = '' WHERE id = ?`),
+			event.TxHash, entry.Requirements.SettleAmount(), id)
+	case types.PaymentStatusFailed:
+		_, err = tx.ExecContext(ctx, l.dialect.rebind(`UPDATE payments SET error = ? WHERE id = ?`), event.Reason, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, l.dialect.rebind(`INSERT INTO payment_events (
+		payment_id, seq, status, reason, tx_hash, request_id, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		id, seq, event.Status, event.Reason, event.TxHash, entry.RequestID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get returns a payment with its events.
+func (l *Ledger) Get(ctx context.Context, id string) (*types.Payment, error) {
+	row := l.db.QueryRowContext(ctx, l.dialect.rebind(`SELECT `+paymentColumns+` FROM payments WHERE id = ?`), id)
+	payment, err := scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := l.db.QueryContext(ctx, l.dialect.rebind(`SELECT status, reason, tx_hash, request_id, created_at
+		FROM payment_events WHERE payment_id = ? ORDER BY seq`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event types.PaymentEvent
+		var createdAt int64
+		if err := rows.Scan(&event.Status, &event.Reason, &event.TxHash, &event.RequestID, &createdAt); err != nil {
+			return nil, err
+		}
+		event.CreatedAt = time.UnixMicro(createdAt).UTC()
+		payment.Events = append(payment.Events, event)
+	}
+	return payment, rows.Err()
+}
+
+const paymentColumns = `id, status, scheme, network, payer, pay_to, asset, amount, resource,
+	invalid_reason, tx_hash, error, request_id, tenant_id, payload, requirements, created_at, updated_at`
+
+func scanPayment(row interface{ Scan(...any) error }) (*types.Payment, error) {
+	var p types.Payment
+	var payload, requirements string
+	var createdAt, updatedAt int64
+	if err := row.Scan(&p.ID, &p.Status, &p.Scheme, &p.Network, &p.Payer, &p.PayTo, &p.Asset, &p.Amount, &p.Resource,
+		&p.InvalidReason, &p.TxHash, &p.Error, &p.RequestID, &p.TenantID, &payload, &requirements, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	p.Payload = json.RawMessage(payload)
+	p.Requirements = json.RawMessage(requirements)
+	p.CreatedAt = time.UnixMicro(createdAt).UTC()
+	p.UpdatedAt = time.UnixMicro(updatedAt).UTC()
+	return &p, nil
+}
+
+// payerOf returns the authorizer of scheme payloads carrying an authorization, such as EIP-3009 transfers,
+// or the owner of payloads carrying a permit, such as Permit2 upto payments.
+func payerOf(payload *types.PaymentPayload) string {
+	var p struct {
+		Authorization struct {
+			From string `json:"from"`
+		} `json:"authorization"`
+		Permit struct {
+			Owner string `json:"owner"`
+		} `json:"permit"`
+	}
+	if err := json.Unmarshal(payload.Payload, &p); err != nil {
+		return ""
+	}
+	if p.Authorization.From == "" {
+		return p.Permit.Owner
+	}
+	return p.Authorization.From
+}
+
+const (
+	// DefaultListLimit is the page size used when Filter.Limit is not set.
+	DefaultListLimit = 50
+	// MaxListLimit bounds the page size.
+	MaxListLimit = 500
+)
+
+// Filter selects payments in List. Empty fields match any payment.
+type Filter struct {
+	Payer    string
+	PayTo    string
+	Network  string
+	Asset    string
+	Resource string
+	Status   types.PaymentStatus
+	TenantID string
+	// Payments created at or after From and before To
+	From time.Time
+	To   time.Time
+
+	Limit int
+	// Cursor returned by the previous page
+	Cursor string
+}
+
+// List returns payments matching filter, newest first, and the cursor of the next page
+// which is empty on the last page. Events are not included.
+func (l *Ledger) List(ctx context.Context, filter Filter) ([]*types.Payment, string, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE 1 = 1`
+	var args []any
+	where := func(cond string, arg any) {
+		query += " AND " + cond
+		args = append(args, arg)
+	}
+
+	// addresses may be checksummed
+	if filter.Payer != "" {
+		where("LOWER(payer) = LOWER(?)", filter.Payer)
+	}
+	if filter.PayTo != "" {
+		where("LOWER(pay_to) = LOWER(?)", filter.PayTo)
+	}
+	if filter.Asset != "" {
+		where("LOWER(asset) = LOWER(?)", filter.Asset)
+	}
+	if filter.Network != "" {
+		where("network = ?", filter.Network)
+	}
+	if filter.Resource != "" {
+		where("resource = ?", filter.Resource)
+	}
+	if filter.Status != "" {
+		where("status = ?", filter.Status)
+	}
+	if filter.TenantID != "" {
+		where("tenant_id = ?", filter.TenantID)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= ?", filter.From.UnixMicro())
+	}
+	if !filter.To.IsZero() {
+		where("created_at < ?", filter.To.UnixMicro())
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+		args = append(args, createdAt, createdAt, id)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+	// one more row tells whether there is a next page
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := l.db.QueryContext(ctx, l.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var payments []*types.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(payments) <= limit {
+		return payments, "", nil
+	}
+	payments = payments[:limit]
+	last := payments[limit-1]
+	return payments, encodeCursor(last.CreatedAt.UnixMicro(), last.ID), nil
+}
+
+func encodeCursor(createdAt int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt, 10) + ":" + id))
+}
+
+func decodeCursor(cursor string) (int64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return 0, "", ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	return micros, id, nil
+}
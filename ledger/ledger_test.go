@@ -0,0 +1,183 @@
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+	"github.com/rabbitprincess/x402-facilitator/webhook"
+)
+
+func openTestLedger(t *testing.T) *Ledger {
+	l, err := Open(t.Context(), Config{Driver: "sqlite", DSN: "file:" + filepath.Join(t.TempDir(), "ledger.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func testEntry(nonce string) Entry {
+	return Entry{
+		Payload: &types.PaymentPayload{
+			X402Version: 1,
+			Scheme:      "exact",
+			Network:     "base-sepolia",
+			Payload:     json.RawMessage(`{"authorization":{"from":"0xpayer","nonce":"` + nonce + `"}}`),
+		},
+		Requirements: &types.PaymentRequirements{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: "1000",
+			Resource:          "https://example.com/resource",
+			PayTo:             "0xmerchant",
+			Asset:             "0xusdc",
+		},
+		RequestID: "req-1",
+		TenantID:  "merchant",
+	}
+}
+
+func TestLedgerRecord(t *testing.T) {
+	l := openTestLedger(t)
+	ctx := t.Context()
+	entry := testEntry("0x01")
+	id, err := PaymentID(entry.Payload)
+	require.NoError(t, err)
+
+	_, err = l.Get(ctx, id)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, l.RecordVerify(ctx, entry, &types.PaymentVerifyResponse{IsValid: true, Payer: "0xpayer"}, nil))
+	payment, err := l.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, types.PaymentStatusVerified, payment.Status)
+	require.Equal(t, "0xpayer", payment.Payer)
+	require.Equal(t, "0xmerchant", payment.PayTo)
+	require.Equal(t, "1000", payment.Amount)
+	require.Equal(t, "merchant", payment.TenantID)
+	require.JSONEq(t, string(entry.Payload.Payload), string(mustPayload(t, payment).Payload))
+
+	entry.RequestID = "req-2"
+	require.NoError(t, l.RecordSettle(ctx, entry, nil, errors.New("rpc unavailable")))
+	require.NoError(t, l.RecordSettle(ctx, entry, &types.PaymentSettleResponse{Success: true, Transaction: "0xtx"}, nil))
+	// a duplicate settlement reverting does not undo the settlement
+	require.NoError(t, l.RecordSettle(ctx, entry, &types.PaymentSettleResponse{Success: false, ErrorReason: "authorization used"}, nil))
+
+	payment, err = l.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, types.PaymentStatusSettled, payment.Status)
+	require.Equal(t, "0xtx", payment.TxHash)
+	require.Equal(t, "req-2", payment.RequestID)
+
+	var statuses []types.PaymentStatus
+	for _, event := range payment.Events {
+		statuses = append(statuses, event.Status)
+	}
+	require.Equal(t, []types.PaymentStatus{
+		types.PaymentStatusVerified,
+		types.PaymentStatusFailed,
+		types.PaymentStatusSettled,
+		types.PaymentStatusFailed,
+	}, statuses)
+	require.Equal(t, "rpc unavailable", payment.Events[1].Reason)
+}
+
+func TestLedgerConcurrentRecords(t *testing.T) {
+	l := openTestLedger(t)
+	ctx := t.Context()
+	entry := testEntry("0x02")
+	id, err := PaymentID(entry.Payload)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- l.RecordVerify(ctx, entry, &types.PaymentVerifyResponse{IsValid: true, Payer: "0xpayer"}, nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	payment, err := l.Get(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, payment.Events, 10)
+
+	// another tenant submitting the same payment does not take it over
+	other := entry
+	other.TenantID = "other"
+	require.NoError(t, l.RecordVerify(ctx, other, &types.PaymentVerifyResponse{IsValid: true, Payer: "0xpayer"}, nil))
+	payment, err = l.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "merchant", payment.TenantID)
+	require.Len(t, payment.Events, 11)
+}
+
+func TestWebhookStore(t *testing.T) {
+	l := openTestLedger(t)
+	store := l.WebhookStore()
+
+	endpoint := &webhook.Endpoint{
+		ID:       "wh_1",
+		URL:      "https://merchant.example.com/webhook",
+		Secret:   "secret",
+		TenantID: "merchant",
+		PayTo:    []string{"0xmerchant"},
+		Events:   []string{webhook.EventPaymentSettled},
+	}
+	require.NoError(t, store.PutEndpoint(t.Context(), endpoint))
+	endpoints, err := store.Endpoints(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []*webhook.Endpoint{endpoint}, endpoints)
+
+	deadLetter := &webhook.DeadLetter{
+		ID:         "dl_1",
+		EndpointID: endpoint.ID,
+		TenantID:   endpoint.TenantID,
+		Event:      webhook.NewEvent(webhook.EventPaymentSettled, webhook.EventData{TxHash: "0xtx"}),
+		Attempts:   3,
+		LastError:  "unexpected status 503",
+		FailedAt:   time.UnixMicro(time.Now().UnixMicro()).UTC(),
+	}
+	deadLetter.Event.CreatedAt = deadLetter.FailedAt
+	require.NoError(t, store.PutDeadLetter(t.Context(), deadLetter))
+	deadLetters, err := store.DeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []*webhook.DeadLetter{deadLetter}, deadLetters)
+
+	require.NoError(t, store.DeleteEndpoint(t.Context(), endpoint.ID))
+	require.NoError(t, store.DeleteDeadLetter(t.Context(), deadLetter.ID))
+	endpoints, err = store.Endpoints(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, endpoints)
+	deadLetters, err = store.DeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, deadLetters)
+}
+
+func TestLedgerMigrateTwice(t *testing.T) {
+	l := openTestLedger(t)
+	require.NoError(t, l.Migrate(t.Context()))
+}
+
+func TestDialectRebind(t *testing.T) {
+	query := `SELECT * FROM payments WHERE payer = ? AND created_at > ?`
+	require.Equal(t, query, SQLite.rebind(query))
+	require.Equal(t, `SELECT * FROM payments WHERE payer = $1 AND created_at > $2`, Postgres.rebind(query))
+}
+
+func mustPayload(t *testing.T, payment *types.Payment) *types.PaymentPayload {
+	var payload types.PaymentPayload
+	require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+	return &payload
+}
@@ -0,0 +1,38 @@
+CREATE TABLE payments (
+    id             TEXT PRIMARY KEY,
+    status         TEXT NOT NULL,
+    scheme         TEXT NOT NULL,
+    network        TEXT NOT NULL,
+    payer          TEXT NOT NULL,
+    pay_to         TEXT NOT NULL,
+    asset          TEXT NOT NULL,
+    amount         TEXT NOT NULL,
+    resource       TEXT NOT NULL,
+    invalid_reason TEXT NOT NULL,
+    tx_hash        TEXT NOT NULL,
+    error          TEXT NOT NULL,
+    request_id     TEXT NOT NULL,
+    tenant_id      TEXT NOT NULL,
+    payload        TEXT NOT NULL,
+    requirements   TEXT NOT NULL,
+    created_at     BIGINT NOT NULL,
+    updated_at     BIGINT NOT NULL,
+    -- events are numbered from a counter of the payment row, incremented under its row lock
+    event_count    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX payments_created_at ON payments (created_at, id);
+CREATE INDEX payments_payer ON payments (payer, created_at);
+CREATE INDEX payments_pay_to ON payments (pay_to, created_at);
+CREATE INDEX payments_tx_hash ON payments (tx_hash);
+
+CREATE TABLE payment_events (
+    payment_id TEXT NOT NULL REFERENCES payments (id),
+    seq        INTEGER NOT NULL,
+    status     TEXT NOT NULL,
+    reason     TEXT NOT NULL,
+    tx_hash    TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (payment_id, seq)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE webhook_endpoints (
+    id         TEXT PRIMARY KEY,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    tenant_id  TEXT NOT NULL,
+    pay_to     TEXT NOT NULL,
+    events     TEXT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE TABLE webhook_dead_letters (
+    id          TEXT PRIMARY KEY,
+    endpoint_id TEXT NOT NULL,
+    tenant_id   TEXT NOT NULL,
+    event       TEXT NOT NULL,
+    attempts    INTEGER NOT NULL,
+    last_error  TEXT NOT NULL,
+    failed_at   BIGINT NOT NULL
+);
@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/webhook"
+)
+
+var _ webhook.Store = (*WebhookStore)(nil)
+
+// WebhookStore persists webhook endpoints and dead letters in the ledger database.
+type WebhookStore struct {
+	ledger *Ledger
+}
+
+// WebhookStore returns the store of webhook endpoints and dead letters of the ledger.
+func (l *Ledger) WebhookStore() *WebhookStore {
+	return &WebhookStore{ledger: l}
+}
+
+func (s *WebhookStore) Endpoints(ctx context.Context) ([]*webhook.Endpoint, error) {
+	l := s.ledger
+	rows, err := l.db.QueryContext(ctx, `SELECT id, url, secret, tenant_id, pay_to, events
+		FROM webhook_endpoints ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*webhook.Endpoint
+	for rows.Next() {
+		var endpoint webhook.Endpoint
+		var payTo, events string
+		if err := rows.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, &endpoint.TenantID, &payTo, &events); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payTo), &endpoint.PayTo); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(events), &endpoint.Events); err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, &endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+func (s *WebhookStore) PutEndpoint(ctx context.Context, endpoint *webhook.Endpoint) error {
+	l := s.ledger
+	payTo, err := json.Marshal(endpoint.PayTo)
+	if err != nil {
+		return err
+	}
+	events, err := json.Marshal(endpoint.Events)
+	if err != nil {
+		return err
+	}
+	_, err = l.db.ExecContext(ctx, l.dialect.rebind(`INSERT INTO webhook_endpoints (
+		id, url, secret, tenant_id, pay_to, events, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		endpoint.ID, endpoint.URL, endpoint.Secret, endpoint.TenantID, string(payTo), string(events), l.now().UnixMicro())
+	return err
+}
+
+func (s *WebhookStore) DeleteEndpoint(ctx context.Context, id string) error {
+	l := s.ledger
+	_, err := l.db.ExecContext(ctx, l.dialect.rebind(`DELETE FROM webhook_endpoints WHERE id = ?`), id)
+	return err
+}
+
+func (s *WebhookStore) DeadLetters(ctx context.Context) ([]*webhook.DeadLetter, error) {
+	l := s.ledger
+	rows, err := l.db.QueryContext(ctx, `SELECT id, endpoint_id, tenant_id, event, attempts, last_error, failed_at
+		FROM webhook_dead_letters ORDER BY failed_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deadLetters []*webhook.DeadLetter
+	for rows.Next() {
+		var deadLetter webhook.DeadLetter
+		var event string
+		var failedAt int64
+		if err := rows.Scan(&deadLetter.ID, &deadLetter.EndpointID, &deadLetter.TenantID, &event,
+			&deadLetter.Attempts, &deadLetter.LastError, &failedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(event), &deadLetter.Event); err != nil {
+			return nil, err
+		}
+		deadLetter.FailedAt = time.UnixMicro(failedAt).UTC()
+		deadLetters = append(deadLetters, &deadLetter)
+	}
+	return deadLetters, rows.Err()
+}
+
+func (s *WebhookStore) PutDeadLetter(ctx context.Context, deadLetter *webhook.DeadLetter) error {
+	l := s.ledger
+	event, err := json.Marshal(deadLetter.Event)
+	if err != nil {
+		return err
+	}
+	_, err = l.db.ExecContext(ctx, l.dialect.rebind(`INSERT INTO webhook_dead_letters (
+		id, endpoint_id, tenant_id, event, attempts, last_error, failed_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		deadLetter.ID, deadLetter.EndpointID, deadLetter.TenantID, string(event),
+		deadLetter.Attempts, deadLetter.LastError, deadLetter.FailedAt.UnixMicro())
+	return err
+}
+
+func (s *WebhookStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	l := s.ledger
+	_, err := l.db.ExecContext(ctx, l.dialect.rebind(`DELETE FROM webhook_dead_letters WHERE id = ?`), id)
+	return err
+}
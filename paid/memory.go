@@ -0,0 +1,90 @@
+package paid
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// pruneInterval is how often expired receipts are dropped.
+const pruneInterval = time.Minute
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps receipts in process memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	receipts  map[string]*entry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type entry struct {
+	// receipt is nil while the key is reserved
+	receipt *types.PaymentReceipt
+	expiry  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		receipts: make(map[string]*entry),
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*types.PaymentReceipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.receipts[key]
+	if !ok || !s.now().Before(e.expiry) {
+		return nil, nil
+	}
+	return e.receipt, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, receipt *types.PaymentReceipt, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	s.receipts[key] = &entry{receipt: receipt, expiry: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	if e, ok := s.receipts[key]; ok && now.Before(e.expiry) {
+		return false, nil
+	}
+	s.receipts[key] = &entry{expiry: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.receipts, key)
+	return nil
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, e := range s.receipts {
+		if !now.Before(e.expiry) {
+			delete(s.receipts, key)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package paid
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// DefaultWindow is used when Config.Window is not set.
+const DefaultWindow = 24 * time.Hour
+
+// PendingTTL is how long a settlement holds its resource before its transaction is mined.
+const PendingTTL = 10 * time.Minute
+
+// Config configures the tracking of one-time resources, which a payer only pays for once within a window.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Time a settlement of a resource is remembered
+	Window time.Duration `mapstructure:"window"`
+	// One-time resources as path.Match patterns of the resource url, empty tracks every resource
+	Resources []string `mapstructure:"resources"`
+}
+
+// Store keeps receipts by resource and payer. Facilitators behind a load balancer
+// share their receipts through an external store.
+type Store interface {
+	// Get returns the receipt stored for key, or nil if there is none or key is only reserved.
+	Get(ctx context.Context, key string) (*types.PaymentReceipt, error)
+	// Put stores the receipt for key for ttl, replacing its reservation.
+	Put(ctx context.Context, key string, receipt *types.PaymentReceipt, ttl time.Duration) error
+	// Reserve atomically reserves key for ttl unless it holds a receipt or a reservation, reporting whether it did.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Delete removes the receipt or reservation of key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Tracker records the settlements of one-time resources and reports payers paying for them again.
+type Tracker struct {
+	config Config
+	store  Store
+}
+
+// NewTracker returns a tracker keeping its receipts in store, in memory if store is nil.
+func NewTracker(config Config, store Store) *Tracker {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Tracker{config: config, store: store}
+}
+
+// Tracks reports whether resource is a one-time resource.
+func (t *Tracker) Tracks(resource string) bool {
+	if resource == "" {
+		return false
+	}
+	if len(t.config.Resources) == 0 {
+		return true
+	}
+	for _, pattern := range t.config.Resources {
+		if ok, _ := path.Match(pattern, resource); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Paid returns the receipt of payer's settlement of resource within the window, or nil if there is none.
+func (t *Tracker) Paid(ctx context.Context, resource, payer string) (*types.PaymentReceipt, error) {
+	if !t.Tracks(resource) {
+		return nil, nil
+	}
+	return t.store.Get(ctx, key(resource, payer))
+}
+
+// Reserve reserves resource for a settlement by payer until it is recorded or released, reporting false
+// if payer already paid for it or another settlement holds it. Untracked resources are always reserved.
+func (t *Tracker) Reserve(ctx context.Context, resource, payer string) (bool, error) {
+	if !t.Tracks(resource) {
+		return true, nil
+	}
+	return t.store.Reserve(ctx, key(resource, payer), PendingTTL)
+}
+
+// Release releases the reservation of resource by payer, its settlement having failed.
+func (t *Tracker) Release(ctx context.Context, resource, payer string) error {
+	if !t.Tracks(resource) {
+		return nil
+	}
+	return t.store.Delete(ctx, key(resource, payer))
+}
+
+// Record remembers a settlement of a one-time resource for the window.
+func (t *Tracker) Record(ctx context.Context, receipt *types.PaymentReceipt) error {
+	if !t.Tracks(receipt.Resource) {
+		return nil
+	}
+	return t.store.Put(ctx, key(receipt.Resource, receipt.Payer), receipt, t.config.Window)
+}
+
+func key(resource, payer string) string {
+	return strings.ToLower(payer) + " " + resource
+}
@@ -0,0 +1,95 @@
+package paid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	tracker := NewTracker(Config{Enabled: true, Window: time.Hour, Resources: []string{"https://example.com/downloads/*"}}, store)
+	ctx := t.Context()
+
+	download := "https://example.com/downloads/report.pdf"
+	require.True(t, tracker.Tracks(download))
+	require.False(t, tracker.Tracks("https://example.com/api/quote"))
+	require.False(t, tracker.Tracks("https://example.com/downloads/2025/report.pdf"))
+
+	receipt, err := tracker.Paid(ctx, download, "0xPayer")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	require.NoError(t, tracker.Record(ctx, &types.PaymentReceipt{Resource: download, Payer: "0xPayer", TxHash: "0xtx"}))
+	receipt, err = tracker.Paid(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.Equal(t, "0xtx", receipt.TxHash)
+
+	// other payers and resources are not paid for
+	receipt, err = tracker.Paid(ctx, download, "0xother")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+	receipt, err = tracker.Paid(ctx, "https://example.com/downloads/other.pdf", "0xpayer")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	// untracked resources are never recorded
+	require.NoError(t, tracker.Record(ctx, &types.PaymentReceipt{Resource: "https://example.com/api/quote", Payer: "0xpayer"}))
+	receipt, err = tracker.Paid(ctx, "https://example.com/api/quote", "0xpayer")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	// the payer pays again after the window
+	now = now.Add(time.Hour)
+	receipt, err = tracker.Paid(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+}
+
+func TestTrackerReserve(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	tracker := NewTracker(Config{Enabled: true, Window: time.Hour}, store)
+	ctx := t.Context()
+	download := "https://example.com/downloads/report.pdf"
+
+	// a single settlement holds a resource, which is not paid for until recorded
+	reserved, err := tracker.Reserve(ctx, download, "0xPayer")
+	require.NoError(t, err)
+	require.True(t, reserved)
+	reserved, err = tracker.Reserve(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.False(t, reserved)
+	receipt, err := tracker.Paid(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	// a failed settlement releases it
+	require.NoError(t, tracker.Release(ctx, download, "0xpayer"))
+	reserved, err = tracker.Reserve(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// a recorded settlement keeps it for the window
+	require.NoError(t, tracker.Record(ctx, &types.PaymentReceipt{Resource: download, Payer: "0xpayer", TxHash: "0xtx"}))
+	now = now.Add(PendingTTL)
+	reserved, err = tracker.Reserve(ctx, download, "0xpayer")
+	require.NoError(t, err)
+	require.False(t, reserved)
+
+	// the reservation of a settlement never recorded expires
+	other := "https://example.com/downloads/other.pdf"
+	reserved, err = tracker.Reserve(ctx, other, "0xpayer")
+	require.NoError(t, err)
+	require.True(t, reserved)
+	now = now.Add(PendingTTL)
+	reserved, err = tracker.Reserve(ctx, other, "0xpayer")
+	require.NoError(t, err)
+	require.True(t, reserved)
+}
@@ -0,0 +1,218 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var (
+	ErrNetworkNotAllowed     = errors.New("network not allowed")
+	ErrAssetNotAllowed       = errors.New("asset not allowed")
+	ErrPayToNotAllowed       = errors.New("payTo not allowed")
+	ErrRequestMaxExceeded    = errors.New("payment over the per request maximum")
+	ErrDomainDailyCapReached = errors.New("daily cap of the domain reached")
+	ErrDailyCapReached       = errors.New("daily cap reached")
+	ErrTotalCapReached       = errors.New("total cap reached")
+)
+
+// Config configures the spending of a paying client. Amounts are in atomic units of each asset, empty being unlimited.
+type Config struct {
+	// Maximum amount of a single payment
+	MaxPerRequest string `mapstructure:"maxPerRequest"`
+	// Maximum amount paid to a domain per UTC day
+	DomainDailyCap string `mapstructure:"domainDailyCap"`
+	// Maximum amount paid per UTC day
+	DailyCap string `mapstructure:"dailyCap"`
+	// Maximum amount paid since the budget was created
+	TotalCap string `mapstructure:"totalCap"`
+	// Networks, assets and payTo addresses paid, any if empty. Assets are given by contract address or by
+	// symbol of the registry.
+	Networks []string `mapstructure:"networks"`
+	Assets   []string `mapstructure:"assets"`
+	PayTo    []string `mapstructure:"payTo"`
+
+	// Registry resolving assets, evm.DefaultRegistry if nil
+	Registry *evm.Registry `mapstructure:"-"`
+}
+
+// Spend is a payment recorded in the spend log, its asset by contract address if the registry knows it.
+type Spend struct {
+	Time     time.Time `json:"time"`
+	Domain   string    `json:"domain"`
+	Resource string    `json:"resource,omitempty"`
+	Network  string    `json:"network"`
+	Asset    string    `json:"asset"`
+	PayTo    string    `json:"payTo"`
+	Amount   string    `json:"amount"`
+}
+
+// Log keeps the spends of a budget, surviving restarts if persisted.
+type Log interface {
+	// Append records a spend.
+	Append(ctx context.Context, spend *Spend) error
+	// Since returns the spends made at or after t.
+	Since(ctx context.Context, t time.Time) ([]*Spend, error)
+}
+
+// Budget approves payments within its limits before they are signed and logs them.
+type Budget struct {
+	config Config
+	log    Log
+	now    func() time.Time
+
+	maxPerRequest  *big.Int
+	domainDailyCap *big.Int
+	dailyCap       *big.Int
+	totalCap       *big.Int
+
+	mu sync.Mutex
+	// spent is the amount paid since the budget was created by asset
+	spent map[string]*big.Int
+}
+
+// New returns a budget logging its spends in log, in memory if log is nil.
+func New(config Config, log Log) (*Budget, error) {
+	if log == nil {
+		log = NewMemoryLog()
+	}
+	if config.Registry == nil {
+		config.Registry = evm.DefaultRegistry
+	}
+	b := &Budget{config: config, log: log, now: time.Now, spent: make(map[string]*big.Int)}
+	var err error
+	if b.maxPerRequest, err = parseAmount(config.MaxPerRequest); err != nil {
+		return nil, fmt.Errorf("invalid maxPerRequest: %w", err)
+	}
+	if b.domainDailyCap, err = parseAmount(config.DomainDailyCap); err != nil {
+		return nil, fmt.Errorf("invalid domainDailyCap: %w", err)
+	}
+	if b.dailyCap, err = parseAmount(config.DailyCap); err != nil {
+		return nil, fmt.Errorf("invalid dailyCap: %w", err)
+	}
+	if b.totalCap, err = parseAmount(config.TotalCap); err != nil {
+		return nil, fmt.Errorf("invalid totalCap: %w", err)
+	}
+	return b, nil
+}
+
+// Reserve approves paying req for a request to domain, or returns the limit it breaks. Once approved, the payment is
+// signed by sign and, if it succeeds, logged as spent, as a signed payment may be settled whatever the outcome of the
+// request. Payments are approved and signed one at a time, so that concurrent ones do not overrun the caps.
+func (b *Budget) Reserve(ctx context.Context, domain string, req *types.PaymentRequirements, sign func() error) error {
+	if !allowed(b.config.Networks, req.Network) {
+		return fmt.Errorf("%w: %s", ErrNetworkNotAllowed, req.Network)
+	}
+	asset, symbol := assetOf(b.config.Registry, req)
+	if len(b.config.Assets) > 0 && !allowed(b.config.Assets, asset) && (symbol == "" || !allowed(b.config.Assets, symbol)) {
+		return fmt.Errorf("%w: %s", ErrAssetNotAllowed, req.Asset)
+	}
+	if !allowed(b.config.PayTo, req.PayTo) {
+		return fmt.Errorf("%w: %s", ErrPayToNotAllowed, req.PayTo)
+	}
+	amount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok || amount.Sign() < 0 {
+		return fmt.Errorf("invalid amount %s", req.MaxAmountRequired)
+	}
+	if b.maxPerRequest != nil && amount.Cmp(b.maxPerRequest) > 0 {
+		return fmt.Errorf("%w: %s over %s", ErrRequestMaxExceeded, amount, b.maxPerRequest)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now().UTC()
+	if b.domainDailyCap != nil || b.dailyCap != nil {
+		day := now.Truncate(24 * time.Hour)
+		spends, err := b.log.Since(ctx, day)
+		if err != nil {
+			return fmt.Errorf("failed to read spend log: %w", err)
+		}
+		total, domainTotal := new(big.Int).Set(amount), new(big.Int).Set(amount)
+		for _, spend := range spends {
+			if !strings.EqualFold(spend.Asset, asset) {
+				continue
+			}
+			spent, ok := new(big.Int).SetString(spend.Amount, 10)
+			if !ok {
+				continue
+			}
+			total.Add(total, spent)
+			if strings.EqualFold(spend.Domain, domain) {
+				domainTotal.Add(domainTotal, spent)
+			}
+		}
+		if b.domainDailyCap != nil && domainTotal.Cmp(b.domainDailyCap) > 0 {
+			return fmt.Errorf("%w: %s", ErrDomainDailyCapReached, domain)
+		}
+		if b.dailyCap != nil && total.Cmp(b.dailyCap) > 0 {
+			return ErrDailyCapReached
+		}
+	}
+	spent := b.spentAmount(asset)
+	if b.totalCap != nil && new(big.Int).Add(spent, amount).Cmp(b.totalCap) > 0 {
+		return fmt.Errorf("%w: %s over %s", ErrTotalCapReached, new(big.Int).Add(spent, amount), b.totalCap)
+	}
+
+	if err := sign(); err != nil {
+		return err
+	}
+	b.spent[strings.ToLower(asset)] = new(big.Int).Add(spent, amount)
+	return b.log.Append(ctx, &Spend{
+		Time:     now,
+		Domain:   domain,
+		Resource: req.Resource,
+		Network:  req.Network,
+		Asset:    asset,
+		PayTo:    req.PayTo,
+		Amount:   amount.String(),
+	})
+}
+
+// Spent returns the amount paid since the budget was created in asset of network, given by contract address or symbol.
+func (b *Budget) Spent(network, asset string) *big.Int {
+	address, _ := assetOf(b.config.Registry, &types.PaymentRequirements{Network: network, Asset: asset})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return new(big.Int).Set(b.spentAmount(address))
+}
+
+func (b *Budget) spentAmount(asset string) *big.Int {
+	if spent, ok := b.spent[strings.ToLower(asset)]; ok {
+		return spent
+	}
+	return new(big.Int)
+}
+
+// assetOf returns the contract address and symbol of the asset of req, as given and without a symbol if the
+// registry does not know it.
+func assetOf(registry *evm.Registry, req *types.PaymentRequirements) (string, string) {
+	symbol, token := registry.GetToken(req.Network, req.Asset)
+	if token == nil {
+		return req.Asset, ""
+	}
+	return token.VerifyingContract.Hex(), symbol
+}
+
+func allowed(list []string, value string) bool {
+	return len(list) == 0 || slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+func parseAmount(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("%s is not an amount", value)
+	}
+	return amount, nil
+}
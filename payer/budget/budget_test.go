@@ -0,0 +1,110 @@
+package budget
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+const usdc = "0x036CbD53842c5426634e7929541eC2318f3dCF7e"
+
+func requirements(amount string) *types.PaymentRequirements {
+	return &types.PaymentRequirements{
+		Network:           "base-sepolia",
+		Asset:             usdc,
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxAmountRequired: amount,
+	}
+}
+
+func signed() error { return nil }
+
+func TestBudgetLimits(t *testing.T) {
+	b, err := New(Config{
+		MaxPerRequest:  "500",
+		DomainDailyCap: "1000",
+		DailyCap:       "1500",
+		Networks:       []string{"base-sepolia"},
+		Assets:         []string{usdc},
+	}, nil)
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	ctx := t.Context()
+
+	// allowlists
+	other := requirements("100")
+	other.Network = "base"
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", other, signed), ErrNetworkNotAllowed)
+	other = requirements("100")
+	other.Asset = "0xother"
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", other, signed), ErrAssetNotAllowed)
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", requirements("501"), signed), ErrRequestMaxExceeded)
+
+	// the daily cap of a domain, then the global daily cap
+	require.NoError(t, b.Reserve(ctx, "api.example.com", requirements("500"), signed))
+	require.NoError(t, b.Reserve(ctx, "api.example.com", requirements("500"), signed))
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", requirements("1"), signed), ErrDomainDailyCapReached)
+	require.NoError(t, b.Reserve(ctx, "other.example.com", requirements("500"), signed))
+	require.ErrorIs(t, b.Reserve(ctx, "other.example.com", requirements("1"), signed), ErrDailyCapReached)
+
+	// caps reset on the next UTC day
+	now = now.Add(12 * time.Hour)
+	require.NoError(t, b.Reserve(ctx, "api.example.com", requirements("500"), signed))
+
+	_, err = New(Config{DailyCap: "ten"}, nil)
+	require.Error(t, err)
+}
+
+func TestBudgetSpent(t *testing.T) {
+	// assets are allowed by symbol or contract address, either naming the same asset
+	b, err := New(Config{TotalCap: "1000", Assets: []string{"usdc"}}, nil)
+	require.NoError(t, err)
+	ctx := t.Context()
+	bySymbol := requirements("400")
+	bySymbol.Asset = "USDC"
+	require.NoError(t, b.Reserve(ctx, "api.example.com", bySymbol, signed))
+	require.NoError(t, b.Reserve(ctx, "api.example.com", requirements("400"), signed))
+	require.Equal(t, "800", b.Spent("base-sepolia", "USDC").String())
+	other := requirements("100")
+	other.Asset = "0x0000000000000000000000000000000000000001"
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", other, signed), ErrAssetNotAllowed)
+
+	// payments failing to be signed are not spent
+	failed := errors.New("signer unavailable")
+	require.ErrorIs(t, b.Reserve(ctx, "api.example.com", requirements("200"), func() error { return failed }), failed)
+	require.Equal(t, "800", b.Spent("base-sepolia", usdc).String())
+
+	// the total cap counts the payments since the budget was created
+	require.NoError(t, b.Reserve(ctx, "api.example.com", requirements("200"), signed))
+	require.ErrorIs(t, b.Reserve(ctx, "other.example.com", requirements("1"), signed), ErrTotalCapReached)
+}
+
+func TestFileLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spends.jsonl")
+	log, err := OpenFileLog(path)
+	require.NoError(t, err)
+	b, err := New(Config{DailyCap: "1000"}, log)
+	require.NoError(t, err)
+	require.NoError(t, b.Reserve(t.Context(), "api.example.com", requirements("800"), signed))
+	require.NoError(t, log.Close())
+
+	// the spends of the day survive a restart
+	log, err = OpenFileLog(path)
+	require.NoError(t, err)
+	defer log.Close()
+	spends, err := log.Since(t.Context(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, spends, 1)
+	require.Equal(t, "api.example.com", spends[0].Domain)
+	require.Equal(t, "800", spends[0].Amount)
+
+	b, err = New(Config{DailyCap: "1000"}, log)
+	require.NoError(t, err)
+	require.ErrorIs(t, b.Reserve(t.Context(), "api.example.com", requirements("201"), signed), ErrDailyCapReached)
+}
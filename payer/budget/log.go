@@ -0,0 +1,128 @@
+package budget
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// retention is how long spends are kept in memory, covering the current UTC day.
+const retention = 48 * time.Hour
+
+var (
+	_ Log = (*MemoryLog)(nil)
+	_ Log = (*FileLog)(nil)
+)
+
+// MemoryLog keeps the spends of the last days in process memory.
+type MemoryLog struct {
+	mu     sync.Mutex
+	spends []*Spend
+}
+
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+func (l *MemoryLog) Append(ctx context.Context, spend *Spend) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.spends = append(l.spends, spend)
+	cutoff := spend.Time.Add(-retention)
+	for len(l.spends) > 0 && l.spends[0].Time.Before(cutoff) {
+		l.spends = l.spends[1:]
+	}
+	return nil
+}
+
+func (l *MemoryLog) Since(ctx context.Context, t time.Time) ([]*Spend, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var spends []*Spend
+	for _, spend := range l.spends {
+		if !spend.Time.Before(t) {
+			spends = append(spends, spend)
+		}
+	}
+	return spends, nil
+}
+
+// FileLog appends spends to a file of JSON lines, which also serves as the audit trail of the payments made.
+// The spends of the last days are loaded on open, so limits hold across restarts.
+type FileLog struct {
+	memory *MemoryLog
+	mu     sync.Mutex
+	file   *os.File
+}
+
+// OpenFileLog opens the spend log at path, creating it if it does not exist.
+func OpenFileLog(path string) (*FileLog, error) {
+	l := &FileLog{memory: NewMemoryLog()}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+	return l, nil
+}
+
+func (l *FileLog) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	cutoff := time.Now().Add(-retention)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var spend Spend
+		if err := json.Unmarshal(scanner.Bytes(), &spend); err != nil {
+			return fmt.Errorf("invalid spend log %s at line %d: %w", path, line, err)
+		}
+		if spend.Time.Before(cutoff) {
+			continue
+		}
+		l.memory.spends = append(l.memory.spends, &spend)
+	}
+	return scanner.Err()
+}
+
+func (l *FileLog) Append(ctx context.Context, spend *Spend) error {
+	data, err := json.Marshal(spend)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write spend log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write spend log: %w", err)
+	}
+	return l.memory.Append(ctx, spend)
+}
+
+func (l *FileLog) Since(ctx context.Context, t time.Time) ([]*Spend, error) {
+	return l.memory.Since(ctx, t)
+}
+
+func (l *FileLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,150 @@
+package payer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/payer/budget"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var (
+	// ErrNoPayableRequirements is returned when a 402 response accepts no payment the transport may make.
+	ErrNoPayableRequirements = errors.New("no payable payment requirements")
+	// ErrBodyNotReplayable is returned when a request must be paid for but its body cannot be sent again.
+	ErrBodyNotReplayable = errors.New("request body cannot be sent again with a payment")
+	// ErrNoBudget is returned when a request must be paid for by a transport without a budget.
+	ErrNoBudget = errors.New("no budget to pay with")
+)
+
+// Transport is an http.RoundTripper paying for resources answered with 402 Payment Required.
+// It picks the first accepted payment its budget allows, signs an EIP-3009 authorization of the
+// exact scheme for it and sends the request again with the X-PAYMENT header.
+type Transport struct {
+	// Base sends the requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// From is the address paying, whose authorizations Signer signs
+	From   string
+	Signer types.Signer
+
+	// Budget limits the networks, assets and amounts paid and logs each signed payment, no payment
+	// being made if nil
+	Budget *budget.Budget
+	// Registry resolving the accepted assets, evm.DefaultRegistry if nil
+	Registry *evm.Registry
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) registry() *evm.Registry {
+	if t.Registry != nil {
+		return t.Registry
+	}
+	return evm.DefaultRegistry
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base().RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusPaymentRequired || req.Header.Get(types.HeaderPayment) != "" {
+		return res, err
+	}
+
+	var required types.PaymentRequiredResponse
+	err = json.NewDecoder(res.Body).Decode(&required)
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment required response: %w", err)
+	}
+	if t.Budget == nil {
+		return nil, ErrNoBudget
+	}
+
+	// the body is read again before paying, the budget not being charged for a request that is not sent
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, ErrBodyNotReplayable
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	header, err := t.pay(req, required.Accepts)
+	if err != nil {
+		if retry.Body != nil {
+			retry.Body.Close()
+		}
+		return nil, err
+	}
+	retry.Header.Set(types.HeaderPayment, header)
+	return t.base().RoundTrip(retry)
+}
+
+// pay signs a payment of the first accepted requirements the budget allows and returns its X-PAYMENT header.
+func (t *Transport) pay(r *http.Request, accepts []types.PaymentRequirements) (string, error) {
+	reasons := make([]error, 0, len(accepts))
+	for i := range accepts {
+		req := &accepts[i]
+		token, amount, err := payable(t.registry(), req)
+		if err != nil {
+			reasons = append(reasons, err)
+			continue
+		}
+		var payload *evm.EVMPayload
+		var signErr error
+		err = t.Budget.Reserve(r.Context(), r.URL.Hostname(), req, func() error {
+			payload, signErr = t.registry().NewEVMPayload(req.Network, token, t.From, req.PayTo, amount.String(), t.Signer)
+			return signErr
+		})
+		if signErr != nil {
+			return "", signErr
+		}
+		if err != nil {
+			reasons = append(reasons, err)
+			continue
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return "", err
+		}
+		return types.EncodePaymentHeader(&types.PaymentPayload{
+			X402Version: int(types.X402VersionV1),
+			Scheme:      req.Scheme,
+			Network:     req.Network,
+			Payload:     data,
+		})
+	}
+	return "", fmt.Errorf("%w: %w", ErrNoPayableRequirements, errors.Join(reasons...))
+}
+
+// payable returns the token symbol and amount of requirements the transport can pay, or the reason it cannot.
+func payable(registry *evm.Registry, req *types.PaymentRequirements) (string, *big.Int, error) {
+	if req.Scheme != string(types.SchemeExact) {
+		return "", nil, fmt.Errorf("scheme %s not supported", req.Scheme)
+	}
+	token, _ := registry.GetToken(req.Network, req.Asset)
+	if token == "" {
+		return "", nil, fmt.Errorf("asset %s unknown on %s", req.Asset, req.Network)
+	}
+	if !common.IsHexAddress(req.PayTo) {
+		return "", nil, fmt.Errorf("invalid payTo %s", req.PayTo)
+	}
+	amount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+	if !ok || amount.Sign() < 0 {
+		return "", nil, fmt.Errorf("invalid amount %s", req.MaxAmountRequired)
+	}
+	return token, amount, nil
+}
@@ -0,0 +1,187 @@
+package payer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/payer/budget"
+	"github.com/rabbitprincess/x402-facilitator/paywall"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// signatureFacilitator accepts payments whose authorization is signed by its sender
+type signatureFacilitator struct{}
+
+func (signatureFacilitator) Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	var evmPayload evm.EVMPayload
+	if err := json.Unmarshal(payload.Payload, &evmPayload); err != nil {
+		return &types.PaymentVerifyResponse{IsValid: false, InvalidReason: types.ErrInvalidPayload.Error()}, nil
+	}
+	sig, err := evm.ParseSignature(evmPayload.Signature)
+	if err != nil {
+		return &types.PaymentVerifyResponse{IsValid: false, InvalidReason: types.ErrInvalidExactEvmPayloadSignature.Error()}, nil
+	}
+	signer, err := evm.RecoverAddress(evm.HashEip3009(evmPayload.Authorization, evm.GetDomainConfig(payload.Network, "USDC")), sig)
+	if err != nil || signer != evmPayload.Authorization.From || evmPayload.Authorization.Value.String() != req.MaxAmountRequired {
+		return &types.PaymentVerifyResponse{IsValid: false, InvalidReason: types.ErrInvalidExactEvmPayloadSignature.Error()}, nil
+	}
+	return &types.PaymentVerifyResponse{IsValid: true, Payer: signer.Hex()}, nil
+}
+
+func (signatureFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	return &types.PaymentSettleResponse{Success: true, Transaction: "0xtx", Network: req.Network}, nil
+}
+
+func newTestTransport(t *testing.T) *Transport {
+	key, err := hex.DecodeString("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	return &Transport{
+		From:   "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		Signer: evm.NewRawPrivateSigner(key),
+	}
+}
+
+func TestTransport(t *testing.T) {
+	price := func(network, amount string) types.PaymentRequirements {
+		return types.PaymentRequirements{
+			Network:           network,
+			MaxAmountRequired: amount,
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			Asset:             evm.GetDomainConfig(network, "USDC").VerifyingContract.Hex(),
+		}
+	}
+	p, err := paywall.New(signatureFacilitator{}, paywall.Options{BaseURL: "https://api.example.com"})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	served := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(append([]byte("paid "), body...))
+	}
+	// base is preferred, base-sepolia is accepted as well
+	mux.Handle("/cheap", p.Handler(http.HandlerFunc(served), price("base", "1000"), price("base-sepolia", "1000")))
+	mux.Handle("/expensive", p.Handler(http.HandlerFunc(served), price("base-sepolia", "1000000")))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	transport := newTestTransport(t)
+	transport.Budget, err = budget.New(budget.Config{
+		Networks:      []string{"base-sepolia"},
+		Assets:        []string{"USDC"},
+		MaxPerRequest: "10000",
+		TotalCap:      "2500",
+	}, nil)
+	require.NoError(t, err)
+	c := &http.Client{Transport: transport}
+
+	// the request is paid on the allowed network and sent again with its body
+	res, err := c.Post(srv.URL+"/cheap", "text/plain", strings.NewReader("request"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	require.Equal(t, "paid request", string(body))
+	settlement, err := types.DecodePaymentResponseHeader(res.Header.Get(types.HeaderPaymentResponse))
+	require.NoError(t, err)
+	require.Equal(t, "base-sepolia", settlement.Network)
+	require.Equal(t, "1000", transport.Budget.Spent("base-sepolia", "USDC").String())
+
+	// requests whose body cannot be sent again are not paid for
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/cheap", io.NopCloser(strings.NewReader("request")))
+	require.NoError(t, err)
+	_, err = c.Do(req)
+	require.ErrorIs(t, err, ErrBodyNotReplayable)
+	require.Equal(t, "1000", transport.Budget.Spent("base-sepolia", "USDC").String())
+
+	// payments over the maximum amount are refused
+	_, err = c.Get(srv.URL + "/expensive")
+	require.ErrorIs(t, err, ErrNoPayableRequirements)
+	require.ErrorIs(t, err, budget.ErrRequestMaxExceeded)
+
+	// and so are payments over the total cap
+	_, err = c.Get(srv.URL + "/cheap")
+	require.NoError(t, err)
+	_, err = c.Get(srv.URL + "/cheap")
+	require.ErrorIs(t, err, ErrNoPayableRequirements)
+	require.ErrorIs(t, err, budget.ErrTotalCapReached)
+	require.Equal(t, "2000", transport.Budget.Spent("base-sepolia", "USDC").String())
+
+	// nothing is paid without a budget
+	_, err = (&http.Client{Transport: newTestTransport(t)}).Get(srv.URL + "/cheap")
+	require.ErrorIs(t, err, ErrNoBudget)
+
+	// daily caps are per domain and asset
+	transport = newTestTransport(t)
+	transport.Budget, err = budget.New(budget.Config{DomainDailyCap: "1000", Networks: []string{"base-sepolia"}}, nil)
+	require.NoError(t, err)
+	c = &http.Client{Transport: transport}
+	res, err = c.Get(srv.URL + "/cheap")
+	require.NoError(t, err)
+	res.Body.Close()
+	_, err = c.Get(srv.URL + "/cheap")
+	require.ErrorIs(t, err, ErrNoPayableRequirements)
+	require.ErrorIs(t, err, budget.ErrDomainDailyCapReached)
+}
+
+func TestTransportRegistry(t *testing.T) {
+	registry := evm.NewRegistry()
+	pyusd := "0x0000000000000000000000000000000000990001"
+	require.NoError(t, registry.Register(map[string]evm.ChainConfig{
+		"registry-l2": {
+			ChainID: 990001,
+			Tokens: map[string]evm.TokenConfig{
+				"PYUSD": {Address: pyusd, Name: "PayPal USD", Version: "1", Decimals: 6},
+			},
+		},
+	}))
+	price := types.PaymentRequirements{
+		Scheme:            string(types.SchemeExact),
+		Network:           "registry-l2",
+		MaxAmountRequired: "1000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		Asset:             pyusd,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(types.HeaderPayment)
+		if header == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(types.PaymentRequiredResponse{X402Version: 1, Accepts: []types.PaymentRequirements{price}})
+			return
+		}
+		// the payment is signed for the token of the registry
+		payment, err := types.DecodePaymentHeader(header)
+		require.NoError(t, err)
+		var payload evm.EVMPayload
+		require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+		sig, err := evm.ParseSignature(payload.Signature)
+		require.NoError(t, err)
+		signer, err := evm.RecoverAddress(evm.HashEip3009(payload.Authorization, registry.GetDomainConfig("registry-l2", "PYUSD")), sig)
+		require.NoError(t, err)
+		require.Equal(t, payload.Authorization.From, signer)
+	}))
+	defer srv.Close()
+
+	// tokens of the registry only are paid
+	transport := newTestTransport(t)
+	var err error
+	transport.Budget, err = budget.New(budget.Config{Assets: []string{"PYUSD"}, Registry: registry}, nil)
+	require.NoError(t, err)
+	_, err = (&http.Client{Transport: transport}).Get(srv.URL)
+	require.ErrorIs(t, err, ErrNoPayableRequirements)
+
+	transport.Registry = registry
+	res, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "1000", transport.Budget.Spent("registry-l2", "PYUSD").String())
+}
@@ -0,0 +1,237 @@
+package paywall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var _ Facilitator = (*client.Client)(nil)
+
+// DefaultMaxTimeoutSeconds is used for accepted payments without MaxTimeoutSeconds.
+const DefaultMaxTimeoutSeconds = 60
+
+// Facilitator verifies and settles payments, as api/client.Client does against a facilitator server.
+type Facilitator interface {
+	Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error)
+	Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error)
+}
+
+// Paywall serves resources only to requests paying for them with an X-PAYMENT header.
+// A request without a valid payment gets 402 listing the accepted payments, a paid request
+// is served and its payment settled once the resource was served successfully.
+type Paywall struct {
+	facilitator Facilitator
+	baseURL     string
+}
+
+// Options configures a Paywall.
+type Options struct {
+	// BaseURL is the public URL of the resource server, such as https://api.example.com, the path of a request
+	// is appended to for payments without a resource. The host of a request is chosen by its client, it does
+	// not name the paid resource.
+	BaseURL string
+}
+
+// New returns a paywall verifying and settling payments with facilitator.
+func New(facilitator Facilitator, opts Options) (*Paywall, error) {
+	if opts.BaseURL != "" {
+		u, err := url.Parse(opts.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid base url: %s", opts.BaseURL)
+		}
+	}
+	return &Paywall{facilitator: facilitator, baseURL: strings.TrimSuffix(opts.BaseURL, "/")}, nil
+}
+
+// Handler wraps next, accepting any of the payments of accepts. Payments without a resource
+// are for the path of the request under Options.BaseURL, Handler panics if there is none.
+func (p *Paywall) Handler(next http.Handler, accepts ...types.PaymentRequirements) http.Handler {
+	for _, req := range accepts {
+		if req.Resource == "" && p.baseURL == "" {
+			panic("paywall: payment without a resource requires Options.BaseURL")
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.serve(w, r, next, accepts)
+	})
+}
+
+// Middleware returns the net/http middleware of Handler.
+func (p *Paywall) Middleware(accepts ...types.PaymentRequirements) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return p.Handler(next, accepts...)
+	}
+}
+
+// Echo returns the echo middleware of Handler.
+func (p *Paywall) Echo(accepts ...types.PaymentRequirements) echo.MiddlewareFunc {
+	return echo.WrapMiddleware(p.Middleware(accepts...))
+}
+
+func (p *Paywall) serve(w http.ResponseWriter, r *http.Request, next http.Handler, accepts []types.PaymentRequirements) {
+	ctx := r.Context()
+	accepts = p.requirementsOf(r, accepts)
+
+	header := r.Header.Get(types.HeaderPayment)
+	if header == "" {
+		paymentRequired(w, "X-PAYMENT header is required", accepts)
+		return
+	}
+	payload, err := types.DecodePaymentHeader(header)
+	if err != nil {
+		paymentRequired(w, err.Error(), accepts)
+		return
+	}
+	req := selectRequirements(payload, accepts)
+	if req == nil {
+		paymentRequired(w, "No accepted payment matches the scheme and network of the payment", accepts)
+		return
+	}
+
+	verified, err := p.facilitator.Verify(ctx, payload, req)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("resource", req.Resource).Msg("Failed to verify payment")
+		paymentRequired(w, "Failed to verify payment", accepts)
+		return
+	}
+	if !verified.IsValid {
+		paymentRequired(w, verified.InvalidReason, accepts)
+		return
+	}
+
+	// the resource is buffered, it is only delivered once its payment is settled
+	payment := &Payment{Payer: verified.Payer, Requirements: *req}
+	rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(rec, r.WithContext(context.WithValue(ctx, paymentKey{}, payment)))
+	if rec.status >= http.StatusBadRequest {
+		rec.flush(w)
+		return
+	}
+
+	req.Amount = payment.amount
+	settled, err := p.facilitator.Settle(ctx, payload, req)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("resource", req.Resource).Msg("Failed to settle payment")
+		paymentRequired(w, "Failed to settle payment", accepts)
+		return
+	}
+	if !settled.Success {
+		paymentRequired(w, settled.ErrorReason, accepts)
+		return
+	}
+	paymentResponse, err := types.EncodePaymentResponseHeader(settled)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to encode payment response")
+	} else {
+		rec.header.Set(types.HeaderPaymentResponse, paymentResponse)
+		rec.header.Set("Access-Control-Expose-Headers", types.HeaderPaymentResponse)
+	}
+	rec.flush(w)
+}
+
+// requirementsOf returns the payments accepted for r with their defaults.
+func (p *Paywall) requirementsOf(r *http.Request, accepts []types.PaymentRequirements) []types.PaymentRequirements {
+	requirements := make([]types.PaymentRequirements, len(accepts))
+	for i, req := range accepts {
+		if req.Scheme == "" {
+			req.Scheme = string(types.SchemeExact)
+		}
+		if req.Resource == "" {
+			req.Resource = p.baseURL + r.URL.Path
+		}
+		if req.MaxTimeoutSeconds == 0 {
+			req.MaxTimeoutSeconds = DefaultMaxTimeoutSeconds
+		}
+		requirements[i] = req
+	}
+	return requirements
+}
+
+// selectRequirements returns the accepted payment of the payload's scheme and network, nil if there is none.
+func selectRequirements(payload *types.PaymentPayload, accepts []types.PaymentRequirements) *types.PaymentRequirements {
+	for _, req := range accepts {
+		if req.Scheme == payload.Scheme && req.Network == payload.Network {
+			return &req
+		}
+	}
+	return nil
+}
+
+func paymentRequired(w http.ResponseWriter, reason string, accepts []types.PaymentRequirements) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	_ = json.NewEncoder(w).Encode(&types.PaymentRequiredResponse{
+		X402Version: int(types.X402VersionV1),
+		Error:       reason,
+		Accepts:     accepts,
+	})
+}
+
+// Payment is the verified payment of a request served behind a paywall.
+type Payment struct {
+	// Address of the payer
+	Payer string
+	// Accepted payment the request pays with
+	Requirements types.PaymentRequirements
+
+	amount string
+}
+
+type paymentKey struct{}
+
+// PaymentFrom returns the verified payment of a request served behind a paywall, nil if there is none.
+func PaymentFrom(ctx context.Context) *Payment {
+	payment, _ := ctx.Value(paymentKey{}).(*Payment)
+	return payment
+}
+
+// Charge sets the amount in atomic units settled for the request under the upto scheme, which is at most
+// the maximum amount of its payment. The maximum amount is settled if it is not called.
+func Charge(ctx context.Context, amount string) {
+	if payment := PaymentFrom(ctx); payment != nil {
+		payment.amount = amount
+	}
+}
+
+// responseRecorder buffers a response until its payment is settled
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status, r.wroteHeader = status, true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
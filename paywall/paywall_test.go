@@ -0,0 +1,196 @@
+package paywall
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/api"
+	"github.com/rabbitprincess/x402-facilitator/api/client"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// stubFacilitator accepts payments signed "valid" and records the settled requirements
+type stubFacilitator struct {
+	settled chan *types.PaymentRequirements
+}
+
+func (s *stubFacilitator) Verify(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentVerifyResponse, error) {
+	var evmPayload struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(payload.Payload, &evmPayload); err != nil || evmPayload.Signature != "valid" {
+		return &types.PaymentVerifyResponse{IsValid: false, InvalidReason: types.ErrInvalidExactEvmPayloadSignature.Error()}, nil
+	}
+	return &types.PaymentVerifyResponse{IsValid: true, Payer: "0xpayer"}, nil
+}
+
+func (s *stubFacilitator) Settle(ctx context.Context, payload *types.PaymentPayload, req *types.PaymentRequirements) (*types.PaymentSettleResponse, error) {
+	s.settled <- req
+	return &types.PaymentSettleResponse{Success: true, Payer: "0xpayer", Transaction: "0xtx", Network: req.Network}, nil
+}
+
+func (s *stubFacilitator) Supported() []*types.SupportedKind {
+	return []*types.SupportedKind{{X402Version: 1, Scheme: "exact", Network: "base-sepolia"}}
+}
+
+func newTestPaywall(t *testing.T) (*Paywall, *stubFacilitator) {
+	facilitator := &stubFacilitator{settled: make(chan *types.PaymentRequirements, 1)}
+	srv := httptest.NewServer(api.NewServer(facilitator, api.Options{}))
+	t.Cleanup(srv.Close)
+	c, err := client.NewClient(srv.URL)
+	require.NoError(t, err)
+	paywall, err := New(c, Options{BaseURL: "https://api.example.com/"})
+	require.NoError(t, err)
+	return paywall, facilitator
+}
+
+func paymentHeader(t *testing.T, scheme, signature string) string {
+	header, err := types.EncodePaymentHeader(&types.PaymentPayload{
+		X402Version: 1,
+		Scheme:      scheme,
+		Network:     "base-sepolia",
+		Payload:     json.RawMessage(`{"signature":"` + signature + `"}`),
+	})
+	require.NoError(t, err)
+	return header
+}
+
+var weather = types.PaymentRequirements{
+	Network:           "base-sepolia",
+	MaxAmountRequired: "1000",
+	PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+	Asset:             "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+}
+
+func TestPaywall(t *testing.T) {
+	paywall, facilitator := newTestPaywall(t)
+	served := 0
+	handler := paywall.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		require.Equal(t, "0xpayer", PaymentFrom(r.Context()).Payer)
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"weather":"sunny"}`))
+	}), weather)
+
+	request := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/weather", nil)
+		if header != "" {
+			req.Header.Set(types.HeaderPayment, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	paymentRequired := func(rec *httptest.ResponseRecorder) *types.PaymentRequiredResponse {
+		require.Equal(t, http.StatusPaymentRequired, rec.Code)
+		var res types.PaymentRequiredResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return &res
+	}
+
+	t.Run("payment required", func(t *testing.T) {
+		res := paymentRequired(request(""))
+		require.Equal(t, int(types.X402VersionV1), res.X402Version)
+		require.Len(t, res.Accepts, 1)
+		accepted := res.Accepts[0]
+		require.Equal(t, "exact", accepted.Scheme)
+		require.Equal(t, "https://api.example.com/weather", accepted.Resource)
+		require.Equal(t, DefaultMaxTimeoutSeconds, accepted.MaxTimeoutSeconds)
+		require.Equal(t, weather.MaxAmountRequired, accepted.MaxAmountRequired)
+	})
+
+	t.Run("invalid payment", func(t *testing.T) {
+		res := paymentRequired(request(paymentHeader(t, "exact", "forged")))
+		require.Equal(t, types.ErrInvalidExactEvmPayloadSignature.Error(), res.Error)
+		require.NotEmpty(t, paymentRequired(request(paymentHeader(t, "upto", "valid"))).Error, "scheme not accepted")
+		require.NotEmpty(t, paymentRequired(request("not base64")).Error)
+		require.Zero(t, served)
+	})
+
+	t.Run("paid", func(t *testing.T) {
+		rec := request(paymentHeader(t, "exact", "valid"))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"weather":"sunny"}`, rec.Body.String())
+
+		settlement, err := types.DecodePaymentResponseHeader(rec.Header().Get(types.HeaderPaymentResponse))
+		require.NoError(t, err)
+		require.True(t, settlement.Success)
+		require.Equal(t, "0xtx", settlement.Transaction)
+		require.Equal(t, "https://api.example.com/weather", (<-facilitator.settled).Resource)
+	})
+
+	t.Run("host chosen by the client", func(t *testing.T) {
+		// the resource is under the base url whatever the host of the request
+		req := httptest.NewRequest(http.MethodGet, "http://attacker.example/weather", nil)
+		req.Host = "attacker.example"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, "https://api.example.com/weather", paymentRequired(rec).Accepts[0].Resource)
+	})
+
+	t.Run("failed resources are not settled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/weather?fail=1", nil)
+		req.Header.Set(types.HeaderPayment, paymentHeader(t, "exact", "valid"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.Empty(t, rec.Header().Get(types.HeaderPaymentResponse))
+		require.Empty(t, facilitator.settled)
+	})
+}
+
+func TestPaywallOptions(t *testing.T) {
+	for _, baseURL := range []string{"api.example.com", "ftp://api.example.com", "https://", "https://api.example.com/?v=1"} {
+		_, err := New(&stubFacilitator{}, Options{BaseURL: baseURL})
+		require.Error(t, err, baseURL)
+	}
+
+	// payments name their resource or the paywall its base url
+	paywall, err := New(&stubFacilitator{}, Options{})
+	require.NoError(t, err)
+	next := http.NotFoundHandler()
+	require.Panics(t, func() { paywall.Handler(next, weather) })
+	withResource := weather
+	withResource.Resource = "https://api.example.com/weather"
+	require.NotPanics(t, func() { paywall.Handler(next, withResource) })
+}
+
+func TestPaywallEcho(t *testing.T) {
+	paywall, facilitator := newTestPaywall(t)
+	upto := weather
+	upto.Scheme = string(types.SchemeUpto)
+
+	e := echo.New()
+	e.GET("/completions", func(c echo.Context) error {
+		// the metered amount is settled under upto
+		Charge(c.Request().Context(), "420")
+		return c.String(http.StatusOK, "completion")
+	}, paywall.Echo(upto))
+
+	req := httptest.NewRequest(http.MethodGet, "/completions", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusPaymentRequired, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/completions", nil)
+	req.Header.Set(types.HeaderPayment, paymentHeader(t, "upto", "valid"))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "completion", rec.Body.String())
+	require.NotEmpty(t, rec.Header().Get(types.HeaderPaymentResponse))
+
+	settled := <-facilitator.settled
+	require.Equal(t, "420", settled.Amount)
+	require.Equal(t, "1000", settled.MaxAmountRequired)
+}
@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// List is a set of addresses loaded from a file, one per line, ignoring blank lines and # comments.
+// The file is checked for changes at most once per interval and reloaded when modified,
+// a list failing to reload keeping its previous addresses.
+type List struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	addresses map[string]struct{}
+	modTime   time.Time
+	checked   time.Time
+}
+
+// LoadList loads the list of addresses of the file at path.
+func LoadList(path string, interval time.Duration) (*List, error) {
+	l := &List{path: path, interval: interval, now: time.Now}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	l.checked = l.now()
+	return l, nil
+}
+
+// Contains reports whether address is in the list, a nil list containing no address.
+func (l *List) Contains(ctx context.Context, address string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reload(ctx)
+	_, ok := l.addresses[strings.ToLower(address)]
+	return ok
+}
+
+func (l *List) reload(ctx context.Context) {
+	now := l.now()
+	if now.Sub(l.checked) < l.interval {
+		return
+	}
+	l.checked = now
+
+	info, err := os.Stat(l.path)
+	if err == nil && info.ModTime().Equal(l.modTime) {
+		return
+	}
+	if err == nil {
+		err = l.load(info.ModTime())
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", l.path).Msg("Failed to reload address list")
+	}
+}
+
+func (l *List) load(modTime time.Time) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	addresses := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if address := strings.TrimSpace(line); address != "" {
+			addresses[strings.ToLower(address)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read address list %s: %w", l.path, err)
+	}
+	l.addresses = addresses
+	l.modTime = modTime
+	return nil
+}
+
+var _ Screener = (*ListScreener)(nil)
+
+// ListScreener screens addresses against a local sanctions list.
+type ListScreener struct {
+	list *List
+}
+
+func NewListScreener(list *List) *ListScreener {
+	return &ListScreener{list: list}
+}
+
+func (s *ListScreener) Screen(ctx context.Context, address string) (bool, error) {
+	return s.list.Contains(ctx, address), nil
+}
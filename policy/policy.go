@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// DefaultReloadInterval is used when Config.ReloadInterval is not set.
+const DefaultReloadInterval = 30 * time.Second
+
+// Config configures the policy applied to the payers and payees of payments.
+// Lists are files of addresses, one per line, reloaded when they change.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Payers and payees allowed, any if empty
+	AllowPayers string `mapstructure:"allowPayers"`
+	AllowPayees string `mapstructure:"allowPayees"`
+	// Payers and payees denied
+	DenyPayers string `mapstructure:"denyPayers"`
+	DenyPayees string `mapstructure:"denyPayees"`
+	// Local sanctions list screening both payers and payees, none if empty
+	SanctionsList string `mapstructure:"sanctionsList"`
+	// Time between checks of the lists for changes
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+}
+
+// Screener screens addresses against sanctions lists, such as a compliance provider.
+type Screener interface {
+	// Screen reports whether address is sanctioned.
+	Screen(ctx context.Context, address string) (bool, error)
+}
+
+// Engine decides whether a payment from a payer to a payee may be verified and settled.
+type Engine struct {
+	allowPayers *List
+	allowPayees *List
+	denyPayers  *List
+	denyPayees  *List
+	screeners   []Screener
+}
+
+// New returns an engine applying the lists of config and screening addresses with the
+// sanctions list of config and screener, if not nil.
+func New(config Config, screener Screener) (*Engine, error) {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = DefaultReloadInterval
+	}
+	e := &Engine{}
+	for _, list := range []struct {
+		list **List
+		path string
+	}{
+		{&e.allowPayers, config.AllowPayers},
+		{&e.allowPayees, config.AllowPayees},
+		{&e.denyPayers, config.DenyPayers},
+		{&e.denyPayees, config.DenyPayees},
+	} {
+		if list.path == "" {
+			continue
+		}
+		var err error
+		if *list.list, err = LoadList(list.path, config.ReloadInterval); err != nil {
+			return nil, err
+		}
+	}
+	if config.SanctionsList != "" {
+		sanctions, err := LoadList(config.SanctionsList, config.ReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		e.screeners = append(e.screeners, NewListScreener(sanctions))
+	}
+	if screener != nil {
+		e.screeners = append(e.screeners, screener)
+	}
+	return e, nil
+}
+
+// Check returns an error wrapping types.ErrPolicyViolation if the policy rejects a payment from payer to payee,
+// or another error if the addresses could not be screened.
+func (e *Engine) Check(ctx context.Context, payer, payee string) error {
+	for _, screener := range e.screeners {
+		for _, address := range []string{payer, payee} {
+			sanctioned, err := screener.Screen(ctx, address)
+			if err != nil {
+				return fmt.Errorf("failed to screen %s: %w", address, err)
+			}
+			if sanctioned {
+				return fmt.Errorf("%w: %s is sanctioned", types.ErrPolicyViolation, address)
+			}
+		}
+	}
+	if e.denyPayers.Contains(ctx, payer) {
+		return fmt.Errorf("%w: payer %s is denied", types.ErrPolicyViolation, payer)
+	}
+	if e.denyPayees.Contains(ctx, payee) {
+		return fmt.Errorf("%w: payee %s is denied", types.ErrPolicyViolation, payee)
+	}
+	if e.allowPayers != nil && !e.allowPayers.Contains(ctx, payer) {
+		return fmt.Errorf("%w: payer %s is not allowed", types.ErrPolicyViolation, payer)
+	}
+	if e.allowPayees != nil && !e.allowPayees.Contains(ctx, payee) {
+		return fmt.Errorf("%w: payee %s is not allowed", types.ErrPolicyViolation, payee)
+	}
+	return nil
+}
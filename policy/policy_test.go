@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+const (
+	payer    = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	payee    = "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+	stranger = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+)
+
+func writeList(t *testing.T, path string, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+type screenerFunc func(ctx context.Context, address string) (bool, error)
+
+func (f screenerFunc) Screen(ctx context.Context, address string) (bool, error) {
+	return f(ctx, address)
+}
+
+func TestEngine(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{
+		Enabled:       true,
+		AllowPayees:   filepath.Join(dir, "payees.txt"),
+		DenyPayers:    filepath.Join(dir, "deny.txt"),
+		SanctionsList: filepath.Join(dir, "sanctions.txt"),
+	}
+	writeList(t, config.AllowPayees, "# merchants\n"+payee+"\n")
+	writeList(t, config.DenyPayers, "")
+	writeList(t, config.SanctionsList, "0x70997970c51812dc3a010c7d01b50e0d17dc79c8 # lowercase\n")
+	e, err := New(config, nil)
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	require.NoError(t, e.Check(ctx, payer, payee))
+	require.ErrorIs(t, e.Check(ctx, payer, stranger), types.ErrPolicyViolation)
+	require.ErrorIs(t, e.Check(ctx, stranger, payee), types.ErrPolicyViolation)
+
+	// a pluggable screener is consulted as well, failing closed
+	e, err = New(config, screenerFunc(func(ctx context.Context, address string) (bool, error) {
+		return false, errors.New("provider unavailable")
+	}))
+	require.NoError(t, err)
+	err = e.Check(ctx, payer, payee)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, types.ErrPolicyViolation)
+
+	_, err = New(Config{Enabled: true, DenyPayees: filepath.Join(dir, "missing.txt")}, nil)
+	require.Error(t, err)
+}
+
+func TestListReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	writeList(t, path, payer+"\n")
+	list, err := LoadList(path, time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	list.now = func() time.Time { return now }
+	ctx := t.Context()
+	require.True(t, list.Contains(ctx, payer))
+
+	// changes are picked up once the interval elapsed
+	writeList(t, path, stranger+"\n")
+	require.NoError(t, os.Chtimes(path, now.Add(time.Second), now.Add(time.Second)))
+	require.True(t, list.Contains(ctx, payer))
+	now = now.Add(time.Minute)
+	require.False(t, list.Contains(ctx, payer))
+	require.True(t, list.Contains(ctx, stranger))
+
+	// a list failing to reload keeps its addresses
+	require.NoError(t, os.Remove(path))
+	now = now.Add(time.Minute)
+	require.True(t, list.Contains(ctx, stranger))
+
+	var none *List
+	require.False(t, none.Contains(ctx, payer))
+}
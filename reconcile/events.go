@@ -0,0 +1,90 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip3009"
+)
+
+type (
+	transferEvent      = eip3009.Eip3009Transfer
+	authorizationEvent = eip3009.Eip3009AuthorizationUsed
+)
+
+// maxTopics bounds the addresses of a single log query.
+const maxTopics = 100
+
+// eventSource scans the events of a token contract in batches of blocks.
+type eventSource struct {
+	asset     common.Address
+	filterer  *eip3009.Eip3009Filterer
+	batchSize uint64
+}
+
+func newEventSource(asset common.Address, backend bind.ContractFilterer, batchSize uint64) (*eventSource, error) {
+	filterer, err := eip3009.NewEip3009Filterer(asset, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &eventSource{asset: asset, filterer: filterer, batchSize: batchSize}, nil
+}
+
+// transfers returns the transfers to the recipients within blocks.
+func (s *eventSource) transfers(ctx context.Context, blocks [2]uint64, recipients []common.Address) ([]*transferEvent, error) {
+	var transfers []*transferEvent
+	err := s.scan(ctx, blocks, recipients, func(opts *bind.FilterOpts, to []common.Address) error {
+		it, err := s.filterer.FilterTransfer(opts, nil, to)
+		if err != nil {
+			return fmt.Errorf("failed to filter transfers: %w", err)
+		}
+		defer it.Close()
+		for it.Next() {
+			if !it.Event.Raw.Removed {
+				transfers = append(transfers, it.Event)
+			}
+		}
+		return it.Error()
+	})
+	return transfers, err
+}
+
+// authorizations returns the authorizations used by the authorizers within blocks.
+func (s *eventSource) authorizations(ctx context.Context, blocks [2]uint64, authorizers []common.Address) (map[authKey]*authorizationEvent, error) {
+	authorizations := make(map[authKey]*authorizationEvent)
+	err := s.scan(ctx, blocks, authorizers, func(opts *bind.FilterOpts, authorizer []common.Address) error {
+		it, err := s.filterer.FilterAuthorizationUsed(opts, authorizer, nil)
+		if err != nil {
+			return fmt.Errorf("failed to filter used authorizations: %w", err)
+		}
+		defer it.Close()
+		for it.Next() {
+			if !it.Event.Raw.Removed {
+				authorizations[authKey{asset: s.asset, authorizer: it.Event.Authorizer, nonce: it.Event.Nonce}] = it.Event
+			}
+		}
+		return it.Error()
+	})
+	return authorizations, err
+}
+
+// scan calls query for each batch of blocks and addresses, none if there are no addresses,
+// as an empty topic would match the events of every address.
+func (s *eventSource) scan(ctx context.Context, blocks [2]uint64, addresses []common.Address, query func(*bind.FilterOpts, []common.Address) error) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	for start := blocks[0]; start <= blocks[1]; start += s.batchSize {
+		end := min(start+s.batchSize-1, blocks[1])
+		for i := 0; i < len(addresses); i += maxTopics {
+			opts := &bind.FilterOpts{Start: start, End: &end, Context: ctx}
+			if err := query(opts, addresses[i:min(i+maxTopics, len(addresses))]); err != nil {
+				return fmt.Errorf("blocks %d-%d: %w", start, end, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// Kinds of discrepancies between the ledger and the chain.
+const (
+	// KindMissing is a settlement recorded in the ledger without an on-chain authorization
+	KindMissing = "missing"
+	// KindExtra is an on-chain authorization paying a tracked address without a settlement in the ledger
+	KindExtra = "extra"
+	// KindMismatch is a settlement whose on-chain transfer differs from the ledger
+	KindMismatch = "mismatch"
+	// KindUnreconciled is a settlement without an EIP-3009 authorization to match, such as a Permit2 transfer of the upto scheme
+	KindUnreconciled = "unreconciled"
+)
+
+const (
+	// DefaultMargin widens the scanned blocks and ledger entries around the window,
+	// so that settlements recorded just before a boundary and mined after it still match.
+	DefaultMargin = 15 * time.Minute
+	// DefaultBatchSize is the number of blocks per log query.
+	DefaultBatchSize = 2000
+)
+
+// Backend reads token events and block times.
+type Backend interface {
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
+}
+
+// Config selects the settlements to reconcile.
+type Config struct {
+	Network string
+	// EIP-3009 token contracts to reconcile
+	Assets []common.Address
+	// Window of settlements, From inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// Margin around the window, DefaultMargin if zero
+	Margin time.Duration
+	// Blocks per log query, DefaultBatchSize if zero
+	BatchSize uint64
+	// Recipients checked for extra transfers in addition to the payTo addresses found in the ledger
+	PayTo []common.Address
+}
+
+// Report is the outcome of a reconciliation.
+type Report struct {
+	Network   string    `json:"network"`
+	Assets    []string  `json:"assets"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	FromBlock uint64    `json:"fromBlock"`
+	ToBlock   uint64    `json:"toBlock"`
+	// Settlements recorded in the ledger within the window
+	Settlements int `json:"settlements"`
+	// Authorizations used on chain within the window paying a tracked address
+	Authorizations int `json:"authorizations"`
+	// Settlements matching their on-chain transfer
+	Matched int `json:"matched"`
+	// Settlements not matched to the chain, reported as unreconciled
+	Unreconciled  int            `json:"unreconciled"`
+	Discrepancies []*Discrepancy `json:"discrepancies"`
+	GeneratedAt   time.Time      `json:"generatedAt"`
+}
+
+// Discrepancy is a settlement that does not reconcile.
+type Discrepancy struct {
+	Kind       string `json:"kind"`
+	Asset      string `json:"asset"`
+	Authorizer string `json:"authorizer"`
+	Nonce      string `json:"nonce"`
+	// Ledger side
+	PaymentID    string              `json:"paymentId,omitempty"`
+	LedgerStatus types.PaymentStatus `json:"ledgerStatus,omitempty"`
+	LedgerTxHash string              `json:"ledgerTxHash,omitempty"`
+	// Chain side
+	TxHash      string `json:"txHash,omitempty"`
+	BlockNumber uint64 `json:"blockNumber,omitempty"`
+	Detail      string `json:"detail"`
+}
+
+// Reconciler matches the settlements of a ledger to EIP-3009 Transfer and AuthorizationUsed events.
+type Reconciler struct {
+	ledger  *ledger.Ledger
+	backend Backend
+	now     func() time.Time
+}
+
+func New(l *ledger.Ledger, backend Backend) *Reconciler {
+	return &Reconciler{
+		ledger:  l,
+		backend: backend,
+		now:     time.Now,
+	}
+}
+
+// authKey identifies an EIP-3009 authorization, nonces are unique per token and authorizer.
+type authKey struct {
+	asset      common.Address
+	authorizer common.Address
+	nonce      [32]byte
+}
+
+// settlement is a ledger payment with its decoded authorization.
+type settlement struct {
+	payment       *types.Payment
+	authorization *evm.Authorization
+}
+
+// Run reconciles the settlements of config's window and returns the report.
+func (r *Reconciler) Run(ctx context.Context, config Config) (*Report, error) {
+	if !config.From.Before(config.To) {
+		return nil, errors.New("reconciliation window is empty")
+	}
+	if config.Margin <= 0 {
+		config.Margin = DefaultMargin
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	report := &Report{
+		Network:       config.Network,
+		From:          config.From,
+		To:            config.To,
+		Discrepancies: []*Discrepancy{},
+	}
+	for _, asset := range config.Assets {
+		report.Assets = append(report.Assets, asset.Hex())
+	}
+
+	// blocks of the window and of the scanned range around it
+	head, err := r.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	latest := head.Number.Uint64()
+	var windowStart, windowEnd, scanStart, scanEnd uint64
+	for _, b := range []struct {
+		block *uint64
+		at    time.Time
+	}{
+		{&windowStart, config.From},
+		{&windowEnd, config.To},
+		{&scanStart, config.From.Add(-config.Margin)},
+		{&scanEnd, config.To.Add(config.Margin)},
+	} {
+		if *b.block, err = r.blockAt(ctx, b.at, latest); err != nil {
+			return nil, err
+		}
+	}
+	scanEnd = min(scanEnd, latest)
+	report.FromBlock = windowStart
+	report.ToBlock = max(windowEnd, 1) - 1
+
+	for _, asset := range config.Assets {
+		if err := r.reconcileAsset(ctx, config, asset, report, [2]uint64{windowStart, windowEnd}, [2]uint64{scanStart, scanEnd}); err != nil {
+			return nil, fmt.Errorf("asset %s: %w", asset.Hex(), err)
+		}
+	}
+	report.GeneratedAt = r.now().UTC()
+	return report, nil
+}
+
+func (r *Reconciler) reconcileAsset(ctx context.Context, config Config, asset common.Address, report *Report, window, scan [2]uint64) error {
+	settlements, unmatched, err := r.loadSettlements(ctx, config, asset)
+	if err != nil {
+		return err
+	}
+
+	// settlements without an authorization to match
+	for _, payment := range unmatched {
+		if payment.Status != types.PaymentStatusSettled || !inWindow(payment.CreatedAt, config) {
+			continue
+		}
+		report.Settlements++
+		report.Unreconciled++
+		report.Discrepancies = append(report.Discrepancies, &Discrepancy{
+			Kind:         KindUnreconciled,
+			Asset:        asset.Hex(),
+			Authorizer:   payment.Payer,
+			PaymentID:    payment.ID,
+			LedgerStatus: payment.Status,
+			LedgerTxHash: payment.TxHash,
+			Detail:       fmt.Sprintf("settlement of the %s scheme has no EIP-3009 authorization to reconcile", payment.Scheme),
+		})
+	}
+
+	// tracked recipients and the authorizers whose authorizations are looked up
+	tracked := make(map[common.Address]bool)
+	for _, payTo := range config.PayTo {
+		tracked[payTo] = true
+	}
+	authorizers := make(map[common.Address]bool)
+	for key, s := range settlements {
+		tracked[s.authorization.To] = true
+		authorizers[key.authorizer] = true
+	}
+
+	source, err := newEventSource(asset, r.backend, config.BatchSize)
+	if err != nil {
+		return err
+	}
+	transfers, err := source.transfers(ctx, scan, keys(tracked))
+	if err != nil {
+		return err
+	}
+	for _, transfer := range transfers {
+		authorizers[transfer.From] = true
+	}
+	authorizations, err := source.authorizations(ctx, scan, keys(authorizers))
+	if err != nil {
+		return err
+	}
+
+	// transfers by transaction, to find the transfer of an authorization
+	transfersByTx := make(map[common.Hash][]*transferEvent)
+	for _, transfer := range transfers {
+		transfersByTx[transfer.Raw.TxHash] = append(transfersByTx[transfer.Raw.TxHash], transfer)
+	}
+	transferOf := func(auth *authorizationEvent) *transferEvent {
+		for _, transfer := range transfersByTx[auth.Raw.TxHash] {
+			if transfer.From == auth.Authorizer {
+				return transfer
+			}
+		}
+		return nil
+	}
+
+	// ledger settlements of the window
+	for key, s := range settlements {
+		if s.payment.Status != types.PaymentStatusSettled || !inWindow(s.payment.CreatedAt, config) {
+			continue
+		}
+		report.Settlements++
+
+		d := &Discrepancy{
+			Asset:        asset.Hex(),
+			Authorizer:   key.authorizer.Hex(),
+			Nonce:        common.Hash(key.nonce).Hex(),
+			PaymentID:    s.payment.ID,
+			LedgerStatus: s.payment.Status,
+			LedgerTxHash: s.payment.TxHash,
+		}
+		auth, ok := authorizations[key]
+		if !ok {
+			d.Kind, d.Detail = KindMissing, "authorization was not used on chain"
+			report.Discrepancies = append(report.Discrepancies, d)
+			continue
+		}
+		d.TxHash, d.BlockNumber = auth.Raw.TxHash.Hex(), auth.Raw.BlockNumber
+
+		transfer := transferOf(auth)
+		switch {
+		case transfer == nil:
+			d.Kind, d.Detail = KindMismatch, "no transfer to the authorized recipient in the authorization's transaction"
+		case transfer.To != s.authorization.To:
+			d.Kind, d.Detail = KindMismatch, fmt.Sprintf("transferred to %s instead of %s", transfer.To.Hex(), s.authorization.To.Hex())
+		case s.authorization.Value == nil || transfer.Value.Cmp(s.authorization.Value) != 0:
+			d.Kind, d.Detail = KindMismatch, fmt.Sprintf("transferred %s instead of %s", transfer.Value, s.authorization.Value)
+		case !strings.EqualFold(s.payment.TxHash, auth.Raw.TxHash.Hex()):
+			d.Kind, d.Detail = KindMismatch, "settled in a different transaction than recorded"
+		default:
+			report.Matched++
+			continue
+		}
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	// on-chain authorizations of the window paying a tracked recipient
+	for key, auth := range authorizations {
+		if auth.Raw.BlockNumber < window[0] || auth.Raw.BlockNumber >= window[1] {
+			continue
+		}
+		transfer := transferOf(auth)
+		if transfer == nil || !tracked[transfer.To] {
+			continue
+		}
+		report.Authorizations++
+
+		s, ok := settlements[key]
+		if ok && s.payment.Status == types.PaymentStatusSettled {
+			continue
+		}
+		d := &Discrepancy{
+			Kind:        KindExtra,
+			Asset:       asset.Hex(),
+			Authorizer:  key.authorizer.Hex(),
+			Nonce:       common.Hash(key.nonce).Hex(),
+			TxHash:      auth.Raw.TxHash.Hex(),
+			BlockNumber: auth.Raw.BlockNumber,
+			Detail:      fmt.Sprintf("transfer of %s to %s is not recorded as settled", transfer.Value, transfer.To.Hex()),
+		}
+		if ok {
+			d.PaymentID, d.LedgerStatus, d.LedgerTxHash = s.payment.ID, s.payment.Status, s.payment.TxHash
+		}
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+	return nil
+}
+
+// loadSettlements returns the ledger payments of asset within the window and its margin by authorization,
+// and the payments without an EIP-3009 authorization.
+func (r *Reconciler) loadSettlements(ctx context.Context, config Config, asset common.Address) (map[authKey]*settlement, []*types.Payment, error) {
+	settlements := make(map[authKey]*settlement)
+	var unmatched []*types.Payment
+	filter := ledger.Filter{
+		Network: config.Network,
+		Asset:   asset.Hex(),
+		From:    config.From.Add(-config.Margin),
+		To:      config.To.Add(config.Margin),
+		Limit:   ledger.MaxListLimit,
+	}
+	for {
+		payments, cursor, err := r.ledger.List(ctx, filter)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list ledger payments: %w", err)
+		}
+		for _, payment := range payments {
+			authorization, err := decodeAuthorization(payment)
+			if err != nil {
+				// permits of the upto scheme, or payloads that never carried a valid authorization
+				unmatched = append(unmatched, payment)
+				continue
+			}
+			key := authKey{asset: asset, authorizer: authorization.From, nonce: authorization.Nonce}
+			// a settled record of the authorization takes precedence over failed attempts
+			if existing, ok := settlements[key]; ok && existing.payment.Status == types.PaymentStatusSettled {
+				continue
+			}
+			settlements[key] = &settlement{payment: payment, authorization: authorization}
+		}
+		if cursor == "" {
+			return settlements, unmatched, nil
+		}
+		filter.Cursor = cursor
+	}
+}
+
+func decodeAuthorization(payment *types.Payment) (*evm.Authorization, error) {
+	var payload types.PaymentPayload
+	if err := json.Unmarshal(payment.Payload, &payload); err != nil {
+		return nil, err
+	}
+	var evmPayload evm.EVMPayload
+	if err := json.Unmarshal(payload.Payload, &evmPayload); err != nil {
+		return nil, err
+	}
+	if evmPayload.Authorization == nil {
+		return nil, errors.New("payload has no authorization")
+	}
+	return evmPayload.Authorization, nil
+}
+
+// blockAt returns the first block mined at or after t, or latest+1 if there is none yet.
+func (r *Reconciler) blockAt(ctx context.Context, t time.Time, latest uint64) (uint64, error) {
+	lo, hi := uint64(0), latest+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header, err := r.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block %d: %w", mid, err)
+		}
+		if header.Time < uint64(t.Unix()) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+func inWindow(t time.Time, config Config) bool {
+	return !t.Before(config.From) && t.Before(config.To)
+}
+
+func keys[K comparable](m map[K]bool) []K {
+	list := make([]K, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	return list
+}
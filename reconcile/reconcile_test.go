@@ -0,0 +1,211 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/rabbitprincess/x402-facilitator/ledger"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+var (
+	transferTopic          = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	authorizationUsedTopic = crypto.Keccak256Hash([]byte("AuthorizationUsed(address,bytes32)"))
+
+	asset    = common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e")
+	merchant = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	stranger = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// chain is a backend of blocks mined every second from genesis with the logs of a token.
+type chain struct {
+	genesis time.Time
+	head    uint64
+	logs    []ethTypes.Log
+}
+
+func (c *chain) blockAt(t time.Time) uint64 {
+	return uint64(t.Sub(c.genesis) / time.Second)
+}
+
+// use emits the AuthorizationUsed and Transfer events of an authorization settled in block.
+func (c *chain) use(block uint64, tx byte, from, to common.Address, value int64, nonce [32]byte) {
+	txHash := common.Hash{tx}
+	c.logs = append(c.logs,
+		ethTypes.Log{
+			Address:     asset,
+			Topics:      []common.Hash{authorizationUsedTopic, common.BytesToHash(from.Bytes()), nonce},
+			BlockNumber: block,
+			TxHash:      txHash,
+		},
+		ethTypes.Log{
+			Address:     asset,
+			Topics:      []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:        common.BigToHash(big.NewInt(value)).Bytes(),
+			BlockNumber: block,
+			TxHash:      txHash,
+			Index:       1,
+		},
+	)
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	if number == nil {
+		number = new(big.Int).SetUint64(c.head)
+	}
+	return &ethTypes.Header{Number: number, Time: uint64(c.genesis.Unix()) + number.Uint64()}, nil
+}
+
+func (c *chain) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	var logs []ethTypes.Log
+	for _, log := range c.logs {
+		if !slices.Contains(query.Addresses, log.Address) ||
+			log.BlockNumber < query.FromBlock.Uint64() || log.BlockNumber > query.ToBlock.Uint64() {
+			continue
+		}
+		matches := true
+		for i, topics := range query.Topics {
+			if len(topics) > 0 && !slices.Contains(topics, log.Topics[i]) {
+				matches = false
+			}
+		}
+		if matches {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (c *chain) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- ethTypes.Log) (ethereum.Subscription, error) {
+	return nil, ethereum.NotFound
+}
+
+// settle records a settlement of an authorization in the ledger.
+func settle(t *testing.T, l *ledger.Ledger, from, to common.Address, value int64, nonce [32]byte, txHash common.Hash) string {
+	evmPayload, err := json.Marshal(&evm.EVMPayload{
+		Signature:     "0x",
+		Authorization: &evm.Authorization{From: from, To: to, Value: big.NewInt(value), Nonce: nonce},
+	})
+	require.NoError(t, err)
+	entry := ledger.Entry{
+		Payload: &types.PaymentPayload{X402Version: 1, Scheme: "exact", Network: "base-sepolia", Payload: evmPayload},
+		Requirements: &types.PaymentRequirements{
+			Scheme:            "exact",
+			Network:           "base-sepolia",
+			MaxAmountRequired: big.NewInt(value).String(),
+			PayTo:             to.Hex(),
+			Asset:             asset.Hex(),
+		},
+	}
+	require.NoError(t, l.RecordSettle(t.Context(), entry, &types.PaymentSettleResponse{Success: true, Transaction: txHash.Hex()}, nil))
+	id, err := ledger.PaymentID(entry.Payload)
+	require.NoError(t, err)
+	return id
+}
+
+// settleUpto records a settlement of a Permit2 transfer in the ledger.
+func settleUpto(t *testing.T, l *ledger.Ledger, owner, to common.Address, value int64, txHash common.Hash) string {
+	uptoPayload, err := json.Marshal(&evm.UptoPayload{
+		Signature: "0x",
+		Permit: &evm.Permit{
+			Owner:    owner,
+			Token:    asset,
+			Amount:   big.NewInt(value),
+			Nonce:    big.NewInt(1),
+			Deadline: big.NewInt(time.Now().Add(time.Hour).Unix()),
+			Witness:  evm.Witness{PayTo: to},
+		},
+	})
+	require.NoError(t, err)
+	entry := ledger.Entry{
+		Payload: &types.PaymentPayload{X402Version: 1, Scheme: "upto", Network: "base-sepolia", Payload: uptoPayload},
+		Requirements: &types.PaymentRequirements{
+			Scheme:            "upto",
+			Network:           "base-sepolia",
+			MaxAmountRequired: big.NewInt(value).String(),
+			PayTo:             to.Hex(),
+			Asset:             asset.Hex(),
+		},
+	}
+	require.NoError(t, l.RecordSettle(t.Context(), entry, &types.PaymentSettleResponse{Success: true, Transaction: txHash.Hex()}, nil))
+	id, err := ledger.PaymentID(entry.Payload)
+	require.NoError(t, err)
+	return id
+}
+
+func TestReconcile(t *testing.T) {
+	l, err := ledger.Open(t.Context(), ledger.Config{Driver: "sqlite", DSN: "file:" + filepath.Join(t.TempDir(), "ledger.db")})
+	require.NoError(t, err)
+	defer l.Close()
+
+	now := time.Now()
+	c := &chain{genesis: now.Add(-2 * time.Hour), head: uint64(3 * time.Hour / time.Second)}
+	from, to := now.Add(-time.Hour), now.Add(time.Hour)
+	block := c.blockAt(now)
+
+	payer := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	// matched
+	settle(t, l, payer, merchant, 100, [32]byte{1}, common.Hash{1})
+	c.use(block, 1, payer, merchant, 100, [32]byte{1})
+	// missing on chain
+	missing := settle(t, l, payer, merchant, 200, [32]byte{2}, common.Hash{2})
+	// transferred a different amount
+	mismatch := settle(t, l, payer, merchant, 300, [32]byte{3}, common.Hash{3})
+	c.use(block, 3, payer, merchant, 30, [32]byte{3})
+	// paid to the merchant without a settlement
+	c.use(block+1, 4, stranger, merchant, 400, [32]byte{4})
+	// paid to an untracked recipient
+	c.use(block+1, 5, stranger, stranger, 500, [32]byte{5})
+	// paid to the merchant outside the window
+	c.use(c.blockAt(from)-1, 6, stranger, merchant, 600, [32]byte{6})
+	// settled with a Permit2 transfer
+	unreconciled := settleUpto(t, l, payer, merchant, 700, common.Hash{7})
+
+	report, err := New(l, c).Run(t.Context(), Config{
+		Network:   "base-sepolia",
+		Assets:    []common.Address{asset},
+		From:      from,
+		To:        to,
+		BatchSize: 1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, c.blockAt(from), report.FromBlock)
+	require.Equal(t, c.blockAt(to)-1, report.ToBlock)
+	require.Equal(t, 4, report.Settlements)
+	require.Equal(t, 3, report.Authorizations)
+	require.Equal(t, 1, report.Matched)
+	require.Equal(t, 1, report.Unreconciled)
+
+	discrepancies := make(map[string]*Discrepancy)
+	for _, d := range report.Discrepancies {
+		discrepancies[d.Kind] = d
+	}
+	require.Len(t, report.Discrepancies, 4)
+
+	require.Equal(t, missing, discrepancies[KindMissing].PaymentID)
+	require.Empty(t, discrepancies[KindMissing].TxHash)
+
+	require.Equal(t, mismatch, discrepancies[KindMismatch].PaymentID)
+	require.Equal(t, "transferred 30 instead of 300", discrepancies[KindMismatch].Detail)
+
+	require.Empty(t, discrepancies[KindExtra].PaymentID)
+	require.Equal(t, stranger.Hex(), discrepancies[KindExtra].Authorizer)
+	require.Equal(t, common.Hash{4}.Hex(), discrepancies[KindExtra].TxHash)
+	require.Equal(t, block+1, discrepancies[KindExtra].BlockNumber)
+
+	require.Equal(t, unreconciled, discrepancies[KindUnreconciled].PaymentID)
+	require.Equal(t, common.Hash{7}.Hex(), discrepancies[KindUnreconciled].LedgerTxHash)
+}
@@ -1,19 +1,28 @@
 package evm
 
 import (
+	"maps"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
 func GetChainName(chainID *big.Int) string {
+	return DefaultRegistry.GetChainName(chainID)
+}
+
+func (r *Registry) GetChainName(chainID *big.Int) string {
 	if chainID == nil {
 		return ""
 	}
-	return chainName[int(chainID.Int64())]
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.chainName[int(chainID.Int64())]
 }
 
-var chainName = map[int]string{
+// builtinChainName and builtinChainInfo are the built-in chains every registry starts with.
+var builtinChainName = map[int]string{
 	1:        "ethereum",
 	11155111: "sepolia",
 	8453:     "base",
@@ -22,99 +31,348 @@ var chainName = map[int]string{
 	11155420: "optimism-sepolia",
 	42161:    "arbitrum",
 	421614:   "arbitrum-sepolia",
+	137:      "polygon",
+	80002:    "polygon-amoy",
+	43114:    "avalanche",
+	43113:    "avalanche-fuji",
+	56:       "bsc",
+	97:       "bsc-testnet",
+	59144:    "linea",
+	59141:    "linea-sepolia",
+	1329:     "sei",
+	1328:     "sei-testnet",
 }
 
 type ChainInfo struct {
 	ChainID        *big.Int
 	DefaultUrl     string
-	TokenContracts map[string]DomainConfig
+	TokenContracts map[string]TokenInfo
+}
+
+// TokenInfo describes a token accepted on a chain with the EIP-712 domain of its signatures.
+type TokenInfo struct {
+	DomainConfig
+	Decimals uint8
 }
 
 func GetChainInfo(chain string) *ChainInfo {
-	chainInfo, ok := chainInfo[chain]
+	return DefaultRegistry.GetChainInfo(chain)
+}
+
+func (r *Registry) GetChainInfo(chain string) *ChainInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chainInfo, ok := r.chainInfo[chain]
 	if !ok {
 		return nil
 	}
+	chainInfo.TokenContracts = maps.Clone(chainInfo.TokenContracts)
 	return &chainInfo
 }
 
 func GetChainID(chain string) *big.Int {
-	chainInfo, ok := chainInfo[chain]
+	return DefaultRegistry.GetChainID(chain)
+}
+
+func (r *Registry) GetChainID(chain string) *big.Int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chainInfo, ok := r.chainInfo[chain]
 	if !ok {
 		return nil
 	}
 	return chainInfo.ChainID
 }
 
+// GetDomainConfig looks up the default registry, see Registry.GetDomainConfig.
 func GetDomainConfig(chain, token string) *DomainConfig {
-	chainInfo, ok := chainInfo[chain]
-	if !ok {
+	return DefaultRegistry.GetDomainConfig(chain, token)
+}
+
+// GetDomainConfig returns the EIP-712 domain of token on chain, given by contract address or symbol.
+func (r *Registry) GetDomainConfig(chain, token string) *DomainConfig {
+	_, tokenInfo := r.GetToken(chain, token)
+	if tokenInfo == nil {
 		return nil
 	}
-	domainConfig, ok := chainInfo.TokenContracts[token]
+	return &tokenInfo.DomainConfig
+}
+
+// GetToken looks up the default registry, see Registry.GetToken.
+func GetToken(chain, token string) (string, *TokenInfo) {
+	return DefaultRegistry.GetToken(chain, token)
+}
+
+// GetToken returns the symbol and info of token on chain, given by contract address or symbol,
+// or nil if the token is not accepted on chain.
+func (r *Registry) GetToken(chain, token string) (string, *TokenInfo) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chainInfo, ok := r.chainInfo[chain]
+	if !ok {
+		return "", nil
+	}
+	if common.IsHexAddress(token) {
+		address := common.HexToAddress(token)
+		for symbol, tokenInfo := range chainInfo.TokenContracts {
+			if tokenInfo.VerifyingContract == address {
+				return symbol, &tokenInfo
+			}
+		}
+		return "", nil
+	}
+	for symbol, tokenInfo := range chainInfo.TokenContracts {
+		if strings.EqualFold(symbol, token) {
+			return symbol, &tokenInfo
+		}
+	}
+	return "", nil
+}
+
+// GetTokens looks up the default registry, see Registry.GetTokens.
+func GetTokens(chain string) map[string]TokenInfo {
+	return DefaultRegistry.GetTokens(chain)
+}
+
+// GetTokens returns the tokens accepted on chain by symbol.
+func (r *Registry) GetTokens(chain string) map[string]TokenInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chainInfo, ok := r.chainInfo[chain]
 	if !ok {
 		return nil
 	}
-	return &domainConfig
+	return maps.Clone(chainInfo.TokenContracts)
 }
 
-var chainInfo = map[string]ChainInfo{
+// builtinChainInfo holds the tokens of each chain supporting EIP-3009 transfers with authorization.
+// Chains whose stablecoins lack it, such as the bridged USDC of BNB Smart Chain, declare no token and accept those added by Register.
+// Linea declares none either: its USDC, bridged before Circle upgraded it in place, is only listed once the EIP-712 domain
+// its contract signs with is confirmed, as a wrong name or version would have every authorization rejected on-chain.
+var builtinChainInfo = map[string]ChainInfo{
 	"ethereum": {
-		ChainID: big.NewInt(1),
-		TokenContracts: map[string]DomainConfig{
+		ChainID:    big.NewInt(1),
+		DefaultUrl: "https://ethereum-rpc.publicnode.com",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(1),
+					VerifyingContract: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"sepolia": {
+		ChainID:    big.NewInt(11155111),
+		DefaultUrl: "https://ethereum-sepolia-rpc.publicnode.com",
+		TokenContracts: map[string]TokenInfo{
 			"USDC": {
-				Name:              "USD Coin",
-				Version:           "2",
-				ChainID:           big.NewInt(1),
-				VerifyingContract: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(11155111),
+					VerifyingContract: common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238"),
+				},
+				Decimals: 6,
 			},
 		},
 	},
 	"base": {
 		ChainID:    big.NewInt(8453),
 		DefaultUrl: "https://mainnet.base.org",
-		TokenContracts: map[string]DomainConfig{
+		TokenContracts: map[string]TokenInfo{
 			"USDC": {
-				Name:              "USD Coin",
-				Version:           "2",
-				ChainID:           big.NewInt(8453),
-				VerifyingContract: common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"),
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(8453),
+					VerifyingContract: common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"),
+				},
+				Decimals: 6,
 			},
 		},
 	},
 	"base-sepolia": {
 		ChainID:    big.NewInt(84532),
 		DefaultUrl: "https://sepolia.base.org",
-		TokenContracts: map[string]DomainConfig{
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(84532),
+					VerifyingContract: common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"optimism": {
+		ChainID:    big.NewInt(10),
+		DefaultUrl: "https://mainnet.optimism.io",
+		TokenContracts: map[string]TokenInfo{
 			"USDC": {
-				Name:              "USDC",
-				Version:           "2",
-				ChainID:           big.NewInt(84532),
-				VerifyingContract: common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"),
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(10),
+					VerifyingContract: common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"optimism-sepolia": {
+		ChainID:    big.NewInt(11155420),
+		DefaultUrl: "https://sepolia.optimism.io",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(11155420),
+					VerifyingContract: common.HexToAddress("0x5fd84259d66Cd46123540766Be93DFE6D43130D7"),
+				},
+				Decimals: 6,
 			},
 		},
 	},
 	"arbitrum": {
 		ChainID:    big.NewInt(42161),
 		DefaultUrl: "https://arb1.arbitrum.io/rpc",
-		TokenContracts: map[string]DomainConfig{
+		TokenContracts: map[string]TokenInfo{
 			"USDC": {
-				Name:              "USD Coin",
-				Version:           "2",
-				ChainID:           big.NewInt(42161),
-				VerifyingContract: common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831"),
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(42161),
+					VerifyingContract: common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831"),
+				},
+				Decimals: 6,
 			},
 		},
 	},
 	"arbitrum-sepolia": {
 		ChainID:    big.NewInt(421614),
 		DefaultUrl: "https://sepolia-rollup.arbitrum.io/rpc",
-		TokenContracts: map[string]DomainConfig{
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(421614),
+					VerifyingContract: common.HexToAddress("0x75faf114eafb1BDbe2F0316DF893fd58CE46AA4d"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"polygon": {
+		ChainID:    big.NewInt(137),
+		DefaultUrl: "https://polygon-rpc.com",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(137),
+					VerifyingContract: common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"polygon-amoy": {
+		ChainID:    big.NewInt(80002),
+		DefaultUrl: "https://rpc-amoy.polygon.technology",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(80002),
+					VerifyingContract: common.HexToAddress("0x41E94Eb019C0762f9Bfcf9Fb1E58725BfB0e7582"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"avalanche": {
+		ChainID:    big.NewInt(43114),
+		DefaultUrl: "https://api.avax.network/ext/bc/C/rpc",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(43114),
+					VerifyingContract: common.HexToAddress("0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"avalanche-fuji": {
+		ChainID:    big.NewInt(43113),
+		DefaultUrl: "https://api.avax-test.network/ext/bc/C/rpc",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USD Coin",
+					Version:           "2",
+					ChainID:           big.NewInt(43113),
+					VerifyingContract: common.HexToAddress("0x5425890298aed601595a70AB815c96711a31Bc65"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"bsc": {
+		ChainID:    big.NewInt(56),
+		DefaultUrl: "https://bsc-dataseed.bnbchain.org",
+	},
+	"bsc-testnet": {
+		ChainID:    big.NewInt(97),
+		DefaultUrl: "https://data-seed-prebsc-1-s1.bnbchain.org:8545",
+	},
+	"linea": {
+		ChainID:    big.NewInt(59144),
+		DefaultUrl: "https://rpc.linea.build",
+	},
+	"linea-sepolia": {
+		ChainID:    big.NewInt(59141),
+		DefaultUrl: "https://rpc.sepolia.linea.build",
+	},
+	"sei": {
+		ChainID:    big.NewInt(1329),
+		DefaultUrl: "https://evm-rpc.sei-apis.com",
+		TokenContracts: map[string]TokenInfo{
+			"USDC": {
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(1329),
+					VerifyingContract: common.HexToAddress("0xe15fC38F6D8c56aF07bbCBe3BAf5708A2Bf42392"),
+				},
+				Decimals: 6,
+			},
+		},
+	},
+	"sei-testnet": {
+		ChainID:    big.NewInt(1328),
+		DefaultUrl: "https://evm-rpc-testnet.sei-apis.com",
+		TokenContracts: map[string]TokenInfo{
 			"USDC": {
-				Name:              "USDC",
-				Version:           "2",
-				ChainID:           big.NewInt(421614),
-				VerifyingContract: common.HexToAddress("0x75faf114eafb1BDbe2F0316DF893fd58CE46AA4d"),
+				DomainConfig: DomainConfig{
+					Name:              "USDC",
+					Version:           "2",
+					ChainID:           big.NewInt(1328),
+					VerifyingContract: common.HexToAddress("0x4fCF1784B31630811181f670Aea7A7bEF803eaED"),
+				},
+				Decimals: 6,
 			},
 		},
 	},
@@ -0,0 +1,43 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainConsistency(t *testing.T) {
+	require.Len(t, builtinChainInfo, len(builtinChainName))
+	for id, name := range builtinChainName {
+		info, ok := builtinChainInfo[name]
+		require.True(t, ok, "chain %s has no info", name)
+		require.Equal(t, int64(id), info.ChainID.Int64(), name)
+	}
+	for name, info := range builtinChainInfo {
+		require.Equal(t, name, builtinChainName[int(info.ChainID.Int64())], name)
+		require.NotEmpty(t, info.DefaultUrl, name)
+
+		addresses := make(map[common.Address]bool)
+		for symbol, token := range info.TokenContracts {
+			require.Zero(t, token.ChainID.Cmp(info.ChainID), "%s on %s", symbol, name)
+			require.NotEqual(t, common.Address{}, token.VerifyingContract, "%s on %s", symbol, name)
+			require.False(t, addresses[token.VerifyingContract], "%s on %s", symbol, name)
+			addresses[token.VerifyingContract] = true
+			require.NotEmpty(t, token.Name, "%s on %s", symbol, name)
+			require.NotEmpty(t, token.Version, "%s on %s", symbol, name)
+			require.NotZero(t, token.Decimals, "%s on %s", symbol, name)
+		}
+	}
+}
+
+func TestGetChain(t *testing.T) {
+	require.Equal(t, "optimism", GetChainName(big.NewInt(10)))
+	require.Equal(t, big.NewInt(10), GetChainID("optimism"))
+	require.Equal(t, "https://mainnet.optimism.io", GetChainInfo("optimism").DefaultUrl)
+	require.Equal(t, common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"), GetDomainConfig("polygon", "USDC").VerifyingContract)
+	require.Empty(t, GetTokens("bsc"))
+	require.Nil(t, GetChainInfo("unknown"))
+	require.Empty(t, GetChainName(big.NewInt(999999999)))
+}
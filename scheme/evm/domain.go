@@ -0,0 +1,158 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip5267"
+)
+
+// DefaultDomainTTL is used when a DomainCache is created without a TTL.
+const DefaultDomainTTL = time.Hour
+
+// DomainFailureTTL is how long a DomainCache keeps a failed fetch, for tokens that cannot be read not to be
+// fetched again on every payment, but soon enough once the rpc recovers.
+const DomainFailureTTL = time.Minute
+
+// domainFields are the EIP-5267 fields of a domain of name, version, chain id and verifying contract.
+const domainFields = 0x0f
+
+// ErrDomainMismatch is returned when the on-chain EIP-712 domain of a token differs from the registry.
+var ErrDomainMismatch = errors.New("eip-712 domain mismatch")
+
+// FetchDomain reads the EIP-712 domain of the token at contract with EIP-5267 eip712Domain(),
+// falling back to name() and version() for tokens predating it. version() defaults to fallbackVersion,
+// and the domain is checked against DOMAIN_SEPARATOR() when the token exposes it.
+func FetchDomain(ctx context.Context, caller bind.ContractCaller, chainID *big.Int, contract common.Address, fallbackVersion string) (*DomainConfig, error) {
+	token, err := eip5267.NewEip5267Caller(contract, caller)
+	if err != nil {
+		return nil, fmt.Errorf("contract bind failed: %w", err)
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	if domain, err := token.Eip712Domain(opts); err == nil {
+		// the digests are computed over name, version, chain id and verifying contract
+		if domain.Fields[0] != domainFields {
+			return nil, fmt.Errorf("%w: unsupported fields %#x of %s", ErrDomainMismatch, domain.Fields[0], contract.Hex())
+		}
+		return &DomainConfig{
+			Name:              domain.Name,
+			Version:           domain.Version,
+			ChainID:           domain.ChainId,
+			VerifyingContract: domain.VerifyingContract,
+		}, nil
+	}
+
+	name, err := token.Name(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token name: %w", err)
+	}
+	version, err := token.Version(opts)
+	if err != nil {
+		version = fallbackVersion
+	}
+	domain := &DomainConfig{
+		Name:              name,
+		Version:           version,
+		ChainID:           chainID,
+		VerifyingContract: contract,
+	}
+	if separator, err := token.DOMAINSEPARATOR(opts); err == nil && !bytes.Equal(separator[:], domain.ToMessageHash()) {
+		return nil, fmt.Errorf("%w: domain separator of %s does not match name %q and version %q", ErrDomainMismatch, contract.Hex(), name, version)
+	}
+	return domain, nil
+}
+
+// CheckDomain returns an error wrapping ErrDomainMismatch if the on-chain domain differs from the expected one.
+func CheckDomain(expected, onchain *DomainConfig) error {
+	switch {
+	case expected.Name != onchain.Name:
+		return fmt.Errorf("%w: name %q on-chain, %q expected", ErrDomainMismatch, onchain.Name, expected.Name)
+	case expected.Version != onchain.Version:
+		return fmt.Errorf("%w: version %q on-chain, %q expected", ErrDomainMismatch, onchain.Version, expected.Version)
+	case expected.ChainID.Cmp(onchain.ChainID) != 0:
+		return fmt.Errorf("%w: chain id %s on-chain, %s expected", ErrDomainMismatch, onchain.ChainID, expected.ChainID)
+	case expected.VerifyingContract != onchain.VerifyingContract:
+		return fmt.Errorf("%w: verifying contract %s on-chain, %s expected", ErrDomainMismatch, onchain.VerifyingContract.Hex(), expected.VerifyingContract.Hex())
+	}
+	return nil
+}
+
+// DomainCache keeps the on-chain EIP-712 domains of tokens for a TTL, and the failures to fetch them
+// for DomainFailureTTL or the TTL if shorter.
+type DomainCache struct {
+	caller bind.ContractCaller
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	domains map[common.Address]*cachedDomain
+}
+
+type cachedDomain struct {
+	domain *DomainConfig
+	// error of a failed fetch, set instead of domain
+	err    error
+	expiry time.Time
+}
+
+// NewDomainCache returns a cache fetching domains through caller, kept for ttl or DefaultDomainTTL if not positive.
+func NewDomainCache(caller bind.ContractCaller, ttl time.Duration) *DomainCache {
+	if ttl <= 0 {
+		ttl = DefaultDomainTTL
+	}
+	return &DomainCache{
+		caller:  caller,
+		ttl:     ttl,
+		now:     time.Now,
+		domains: make(map[common.Address]*cachedDomain),
+	}
+}
+
+// Domain returns the on-chain domain of the token of the expected domain, fetching it if not cached.
+// A failed fetch is returned again until it expires, except when ctx is done.
+func (c *DomainCache) Domain(ctx context.Context, expected *DomainConfig) (*DomainConfig, error) {
+	if cached := c.cached(expected.VerifyingContract); cached != nil {
+		return cached.domain, cached.err
+	}
+	domain, err := FetchDomain(ctx, c.caller, expected.ChainID, expected.VerifyingContract, expected.Version)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.domains[expected.VerifyingContract] = &cachedDomain{err: err, expiry: c.now().Add(min(c.ttl, DomainFailureTTL))}
+		return nil, err
+	}
+	c.domains[expected.VerifyingContract] = &cachedDomain{domain: domain, expiry: c.now().Add(c.ttl)}
+	return domain, nil
+}
+
+// Cached returns the cached domain of the token at contract, or nil if it is not cached, expired or failed to be fetched.
+func (c *DomainCache) Cached(contract common.Address) *DomainConfig {
+	if cached := c.cached(contract); cached != nil {
+		return cached.domain
+	}
+	return nil
+}
+
+// cached returns the unexpired entry of the token at contract, or nil if there is none.
+func (c *DomainCache) cached(contract common.Address) *cachedDomain {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.domains[contract]
+	if !ok || !c.now().Before(cached.expiry) {
+		return nil
+	}
+	return cached
+}
@@ -0,0 +1,118 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip5267"
+)
+
+// tokenCaller answers the domain calls of a token, reverting the methods it does not implement
+type tokenCaller struct {
+	t       *testing.T
+	results map[string][]any
+	calls   int
+}
+
+func (c *tokenCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (c *tokenCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls++
+	abi, err := eip5267.Eip5267MetaData.GetAbi()
+	require.NoError(c.t, err)
+	method, err := abi.MethodById(call.Data)
+	require.NoError(c.t, err)
+	results, ok := c.results[method.Name]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return method.Outputs.Pack(results...)
+}
+
+func TestFetchDomain(t *testing.T) {
+	usdc := *GetDomainConfig("base-sepolia", "USDC")
+
+	// tokens implementing EIP-5267
+	caller := &tokenCaller{t: t, results: map[string][]any{
+		"eip712Domain": {[1]byte{0x0f}, "USDC", "2", usdc.ChainID, usdc.VerifyingContract, [32]byte{}, []*big.Int{}},
+	}}
+	domain, err := FetchDomain(t.Context(), caller, usdc.ChainID, usdc.VerifyingContract, "")
+	require.NoError(t, err)
+	require.NoError(t, CheckDomain(&usdc, domain))
+
+	// tokens predating it, checked against their domain separator
+	caller.results = map[string][]any{
+		"name":             {"USD Coin"},
+		"DOMAIN_SEPARATOR": {[32]byte(DomainConfig{Name: "USD Coin", Version: "2", ChainID: usdc.ChainID, VerifyingContract: usdc.VerifyingContract}.ToMessageHash())},
+	}
+	domain, err = FetchDomain(t.Context(), caller, usdc.ChainID, usdc.VerifyingContract, "2")
+	require.NoError(t, err)
+	require.Equal(t, "USD Coin", domain.Name)
+	require.Equal(t, "2", domain.Version)
+	require.ErrorIs(t, CheckDomain(&usdc, domain), ErrDomainMismatch)
+
+	_, err = FetchDomain(t.Context(), caller, usdc.ChainID, usdc.VerifyingContract, "1")
+	require.ErrorIs(t, err, ErrDomainMismatch)
+}
+
+func TestDomainCache(t *testing.T) {
+	usdc := *GetDomainConfig("base-sepolia", "USDC")
+	caller := &tokenCaller{t: t, results: map[string][]any{
+		"eip712Domain": {[1]byte{0x0f}, "USDC", "2", usdc.ChainID, usdc.VerifyingContract, [32]byte{}, []*big.Int{}},
+	}}
+	cache := NewDomainCache(caller, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	require.Nil(t, cache.Cached(usdc.VerifyingContract))
+	for range 2 {
+		domain, err := cache.Domain(t.Context(), &usdc)
+		require.NoError(t, err)
+		require.Equal(t, "USDC", domain.Name)
+	}
+	require.Equal(t, 1, caller.calls)
+	require.NotNil(t, cache.Cached(usdc.VerifyingContract))
+
+	// expired domains are fetched again
+	now = now.Add(time.Minute)
+	_, err := cache.Domain(t.Context(), &usdc)
+	require.NoError(t, err)
+	require.Equal(t, 2, caller.calls)
+
+	// failures are kept for DomainFailureTTL
+	failing := &tokenCaller{t: t}
+	cache = NewDomainCache(failing, time.Hour)
+	cache.now = func() time.Time { return now }
+	for range 2 {
+		_, err = cache.Domain(t.Context(), &usdc)
+		require.Error(t, err)
+	}
+	calls := failing.calls
+	require.Nil(t, cache.Cached(usdc.VerifyingContract))
+
+	failing.results = caller.results
+	_, err = cache.Domain(t.Context(), &usdc)
+	require.Error(t, err)
+	require.Equal(t, calls, failing.calls)
+	now = now.Add(DomainFailureTTL)
+	domain, err := cache.Domain(t.Context(), &usdc)
+	require.NoError(t, err)
+	require.Equal(t, "USDC", domain.Name)
+
+	// fetches cut short by their context are not kept
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	cache = NewDomainCache(&tokenCaller{t: t}, time.Hour)
+	_, err = cache.Domain(ctx, &usdc)
+	require.Error(t, err)
+	require.Nil(t, cache.cached(usdc.VerifyingContract))
+}
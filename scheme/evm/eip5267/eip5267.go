@@ -0,0 +1,344 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package eip5267
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// Eip5267MetaData contains all meta data concerning the Eip5267 contract.
+var Eip5267MetaData = &bind.MetaData{
+	ABI: "[{\"name\":\"eip712Domain\",\"type\":\"function\",\"inputs\":[],\"outputs\":[{\"name\":\"fields\",\"type\":\"bytes1\"},{\"name\":\"name\",\"type\":\"string\"},{\"name\":\"version\",\"type\":\"string\"},{\"name\":\"chainId\",\"type\":\"uint256\"},{\"name\":\"verifyingContract\",\"type\":\"address\"},{\"name\":\"salt\",\"type\":\"bytes32\"},{\"name\":\"extensions\",\"type\":\"uint256[]\"}],\"stateMutability\":\"view\"},{\"name\":\"name\",\"type\":\"function\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\"},{\"name\":\"version\",\"type\":\"function\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\"},{\"name\":\"DOMAIN_SEPARATOR\",\"type\":\"function\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\"}]",
+}
+
+// Eip5267ABI is the input ABI used to generate the binding from.
+// Deprecated: Use Eip5267MetaData.ABI instead.
+var Eip5267ABI = Eip5267MetaData.ABI
+
+// Eip5267 is an auto generated Go binding around an Ethereum contract.
+type Eip5267 struct {
+	Eip5267Caller     // Read-only binding to the contract
+	Eip5267Transactor // Write-only binding to the contract
+	Eip5267Filterer   // Log filterer for contract events
+}
+
+// Eip5267Caller is an auto generated read-only Go binding around an Ethereum contract.
+type Eip5267Caller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// Eip5267Transactor is an auto generated write-only Go binding around an Ethereum contract.
+type Eip5267Transactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// Eip5267Filterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type Eip5267Filterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// Eip5267Session is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type Eip5267Session struct {
+	Contract     *Eip5267          // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// Eip5267CallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type Eip5267CallerSession struct {
+	Contract *Eip5267Caller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts  // Call options to use throughout this session
+}
+
+// Eip5267TransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type Eip5267TransactorSession struct {
+	Contract     *Eip5267Transactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts  // Transaction auth options to use throughout this session
+}
+
+// Eip5267Raw is an auto generated low-level Go binding around an Ethereum contract.
+type Eip5267Raw struct {
+	Contract *Eip5267 // Generic contract binding to access the raw methods on
+}
+
+// Eip5267CallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type Eip5267CallerRaw struct {
+	Contract *Eip5267Caller // Generic read-only contract binding to access the raw methods on
+}
+
+// Eip5267TransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type Eip5267TransactorRaw struct {
+	Contract *Eip5267Transactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewEip5267 creates a new instance of Eip5267, bound to a specific deployed contract.
+func NewEip5267(address common.Address, backend bind.ContractBackend) (*Eip5267, error) {
+	contract, err := bindEip5267(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Eip5267{Eip5267Caller: Eip5267Caller{contract: contract}, Eip5267Transactor: Eip5267Transactor{contract: contract}, Eip5267Filterer: Eip5267Filterer{contract: contract}}, nil
+}
+
+// NewEip5267Caller creates a new read-only instance of Eip5267, bound to a specific deployed contract.
+func NewEip5267Caller(address common.Address, caller bind.ContractCaller) (*Eip5267Caller, error) {
+	contract, err := bindEip5267(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Eip5267Caller{contract: contract}, nil
+}
+
+// NewEip5267Transactor creates a new write-only instance of Eip5267, bound to a specific deployed contract.
+func NewEip5267Transactor(address common.Address, transactor bind.ContractTransactor) (*Eip5267Transactor, error) {
+	contract, err := bindEip5267(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Eip5267Transactor{contract: contract}, nil
+}
+
+// NewEip5267Filterer creates a new log filterer instance of Eip5267, bound to a specific deployed contract.
+func NewEip5267Filterer(address common.Address, filterer bind.ContractFilterer) (*Eip5267Filterer, error) {
+	contract, err := bindEip5267(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &Eip5267Filterer{contract: contract}, nil
+}
+
+// bindEip5267 binds a generic wrapper to an already deployed contract.
+func bindEip5267(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := Eip5267MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Eip5267 *Eip5267Raw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Eip5267.Contract.Eip5267Caller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Eip5267 *Eip5267Raw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Eip5267.Contract.Eip5267Transactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Eip5267 *Eip5267Raw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Eip5267.Contract.Eip5267Transactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Eip5267 *Eip5267CallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Eip5267.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Eip5267 *Eip5267TransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Eip5267.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Eip5267 *Eip5267TransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Eip5267.Contract.contract.Transact(opts, method, params...)
+}
+
+// DOMAINSEPARATOR is a free data retrieval call binding the contract method 0x3644e515.
+//
+// Solidity: function DOMAIN_SEPARATOR() view returns(bytes32)
+func (_Eip5267 *Eip5267Caller) DOMAINSEPARATOR(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _Eip5267.contract.Call(opts, &out, "DOMAIN_SEPARATOR")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// DOMAINSEPARATOR is a free data retrieval call binding the contract method 0x3644e515.
+//
+// Solidity: function DOMAIN_SEPARATOR() view returns(bytes32)
+func (_Eip5267 *Eip5267Session) DOMAINSEPARATOR() ([32]byte, error) {
+	return _Eip5267.Contract.DOMAINSEPARATOR(&_Eip5267.CallOpts)
+}
+
+// DOMAINSEPARATOR is a free data retrieval call binding the contract method 0x3644e515.
+//
+// Solidity: function DOMAIN_SEPARATOR() view returns(bytes32)
+func (_Eip5267 *Eip5267CallerSession) DOMAINSEPARATOR() ([32]byte, error) {
+	return _Eip5267.Contract.DOMAINSEPARATOR(&_Eip5267.CallOpts)
+}
+
+// Eip712Domain is a free data retrieval call binding the contract method 0x84b0196e.
+//
+// Solidity: function eip712Domain() view returns(bytes1 fields, string name, string version, uint256 chainId, address verifyingContract, bytes32 salt, uint256[] extensions)
+func (_Eip5267 *Eip5267Caller) Eip712Domain(opts *bind.CallOpts) (struct {
+	Fields            [1]byte
+	Name              string
+	Version           string
+	ChainId           *big.Int
+	VerifyingContract common.Address
+	Salt              [32]byte
+	Extensions        []*big.Int
+}, error) {
+	var out []interface{}
+	err := _Eip5267.contract.Call(opts, &out, "eip712Domain")
+
+	outstruct := new(struct {
+		Fields            [1]byte
+		Name              string
+		Version           string
+		ChainId           *big.Int
+		VerifyingContract common.Address
+		Salt              [32]byte
+		Extensions        []*big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Fields = *abi.ConvertType(out[0], new([1]byte)).(*[1]byte)
+	outstruct.Name = *abi.ConvertType(out[1], new(string)).(*string)
+	outstruct.Version = *abi.ConvertType(out[2], new(string)).(*string)
+	outstruct.ChainId = *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	outstruct.VerifyingContract = *abi.ConvertType(out[4], new(common.Address)).(*common.Address)
+	outstruct.Salt = *abi.ConvertType(out[5], new([32]byte)).(*[32]byte)
+	outstruct.Extensions = *abi.ConvertType(out[6], new([]*big.Int)).(*[]*big.Int)
+
+	return *outstruct, err
+
+}
+
+// Eip712Domain is a free data retrieval call binding the contract method 0x84b0196e.
+//
+// Solidity: function eip712Domain() view returns(bytes1 fields, string name, string version, uint256 chainId, address verifyingContract, bytes32 salt, uint256[] extensions)
+func (_Eip5267 *Eip5267Session) Eip712Domain() (struct {
+	Fields            [1]byte
+	Name              string
+	Version           string
+	ChainId           *big.Int
+	VerifyingContract common.Address
+	Salt              [32]byte
+	Extensions        []*big.Int
+}, error) {
+	return _Eip5267.Contract.Eip712Domain(&_Eip5267.CallOpts)
+}
+
+// Eip712Domain is a free data retrieval call binding the contract method 0x84b0196e.
+//
+// Solidity: function eip712Domain() view returns(bytes1 fields, string name, string version, uint256 chainId, address verifyingContract, bytes32 salt, uint256[] extensions)
+func (_Eip5267 *Eip5267CallerSession) Eip712Domain() (struct {
+	Fields            [1]byte
+	Name              string
+	Version           string
+	ChainId           *big.Int
+	VerifyingContract common.Address
+	Salt              [32]byte
+	Extensions        []*big.Int
+}, error) {
+	return _Eip5267.Contract.Eip712Domain(&_Eip5267.CallOpts)
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_Eip5267 *Eip5267Caller) Name(opts *bind.CallOpts) (string, error) {
+	var out []interface{}
+	err := _Eip5267.contract.Call(opts, &out, "name")
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_Eip5267 *Eip5267Session) Name() (string, error) {
+	return _Eip5267.Contract.Name(&_Eip5267.CallOpts)
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_Eip5267 *Eip5267CallerSession) Name() (string, error) {
+	return _Eip5267.Contract.Name(&_Eip5267.CallOpts)
+}
+
+// Version is a free data retrieval call binding the contract method 0x54fd4d50.
+//
+// Solidity: function version() view returns(string)
+func (_Eip5267 *Eip5267Caller) Version(opts *bind.CallOpts) (string, error) {
+	var out []interface{}
+	err := _Eip5267.contract.Call(opts, &out, "version")
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Version is a free data retrieval call binding the contract method 0x54fd4d50.
+//
+// Solidity: function version() view returns(string)
+func (_Eip5267 *Eip5267Session) Version() (string, error) {
+	return _Eip5267.Contract.Version(&_Eip5267.CallOpts)
+}
+
+// Version is a free data retrieval call binding the contract method 0x54fd4d50.
+//
+// Solidity: function version() view returns(string)
+func (_Eip5267 *Eip5267CallerSession) Version() (string, error) {
+	return _Eip5267.Contract.Version(&_Eip5267.CallOpts)
+}
@@ -0,0 +1,229 @@
+// Package evmtest runs a simulated EVM chain holding a mock EIP-3009 token and a mock Permit2 from its genesis,
+// so that facilitators and clients are tested end to end without network access.
+package evmtest
+
+//go:generate go run gen.go token.sol permit2.sol
+
+import (
+	"context"
+	"crypto/ecdsa"
+	_ "embed"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip3009"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/erc20"
+)
+
+// Network is the name of the simulated chain in the Registry of NewChain, its mock token being its USDC.
+const Network = "simulated"
+
+// Domain of the mock token of NewChain, see token.sol.
+const (
+	TokenName     = "USD Coin"
+	TokenVersion  = "2"
+	TokenDecimals = 6
+)
+
+// token.bin and permit2.bin are the runtime codes of the mock token and Permit2, compiled from token.sol and
+// permit2.sol by gen.go.
+var (
+	//go:embed token.bin
+	tokenCode string
+	//go:embed permit2.bin
+	permit2Code string
+)
+
+// Storage slots of the token's domain name and version, see token.sol.
+const (
+	tokenNameSlot    = 3
+	tokenVersionSlot = 4
+)
+
+const mintABI = `[{"name":"mint","type":"function","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[],"stateMutability":"nonpayable"}]`
+
+// Chain is a simulated chain holding the mock token.
+type Chain struct {
+	Backend *simulated.Backend
+	Client  simulated.Client
+	// Token is the address of the mock token
+	Token common.Address
+	// ChainID is the chain id of the simulated chain
+	ChainID *big.Int
+	// Registry resolves the network of the chain and its mock token
+	Registry *evm.Registry
+
+	minter *ecdsa.PrivateKey
+}
+
+// NewChain starts a simulated chain funding accounts with ether for gas, with the mock token and Permit2,
+// registered as Network in its own Registry. The chain is closed at the end of the test.
+func NewChain(t testing.TB, accounts ...common.Address) *Chain {
+	t.Helper()
+	// the token is where the minter would have deployed it
+	token := crypto.CreateAddress(crypto.PubkeyToAddress(minterKey(t).PublicKey), 0)
+	c := newChain(t, params.AllDevChainProtocolChanges.ChainID, &evm.DomainConfig{
+		Name:              TokenName,
+		Version:           TokenVersion,
+		VerifyingContract: token,
+	}, accounts)
+
+	err := c.Registry.Register(map[string]evm.ChainConfig{
+		Network: {
+			ChainID: c.ChainID.Int64(),
+			Tokens: map[string]evm.TokenConfig{
+				"USDC": {Address: c.Token.Hex(), Name: TokenName, Version: TokenVersion, Decimals: TokenDecimals},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return c
+}
+
+// NewChainOf starts a simulated chain with the chain ID of network in the built-in registry, whose USDC is the mock token
+// at its registered address with its registered EIP-712 domain, so that payments signed for network, such as
+// the x402 reference payments, verify on it. accounts are funded with ether for gas.
+func NewChainOf(t testing.TB, network string, accounts ...common.Address) *Chain {
+	t.Helper()
+	domain := evm.NewRegistry().GetDomainConfig(network, "USDC")
+	require.NotNil(t, domain, "USDC of network %s", network)
+	return newChain(t, domain.ChainID, domain, accounts)
+}
+
+// newChain starts a simulated chain of chainID with the mock token of domain and Permit2.
+func newChain(t testing.TB, chainID *big.Int, domain *evm.DomainConfig, accounts []common.Address) *Chain {
+	minter := minterKey(t)
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+	alloc := ethTypes.GenesisAlloc{crypto.PubkeyToAddress(minter.PublicKey): {Balance: ether}}
+	for _, account := range accounts {
+		alloc[account] = ethTypes.Account{Balance: ether}
+	}
+	// the contracts are placed at their address in the genesis instead of being deployed, Permit2 being at
+	// the same address on every chain and the token's domain being stored with it
+	alloc[domain.VerifyingContract] = ethTypes.Account{
+		Code: code(t, tokenCode),
+		Storage: map[common.Hash]common.Hash{
+			common.BigToHash(big.NewInt(tokenNameSlot)):    shortString(t, domain.Name),
+			common.BigToHash(big.NewInt(tokenVersionSlot)): shortString(t, domain.Version),
+		},
+	}
+	alloc[evm.Permit2Address] = ethTypes.Account{Code: code(t, permit2Code)}
+	backend := simulated.NewBackend(alloc, func(_ *node.Config, ethConf *ethconfig.Config) {
+		config := *ethConf.Genesis.Config
+		config.ChainID = chainID
+		ethConf.Genesis.Config = &config
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	return &Chain{
+		Backend:  backend,
+		Client:   backend.Client(),
+		Token:    domain.VerifyingContract,
+		ChainID:  chainID,
+		Registry: evm.NewRegistry(),
+		minter:   minter,
+	}
+}
+
+// minterKey returns the key of the account minting the token, the same on every chain.
+func minterKey(t testing.TB) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("evmtest deployer")))
+	require.NoError(t, err)
+	return key
+}
+
+// code decodes the hex runtime code compiled by gen.go.
+func code(t testing.TB, hexCode string) []byte {
+	code, err := hex.DecodeString(strings.TrimSpace(hexCode))
+	require.NoError(t, err)
+	return code
+}
+
+// shortString returns the storage value of a string of at most 31 bytes, as solidity lays it out.
+func shortString(t testing.TB, s string) common.Hash {
+	require.Less(t, len(s), 32, "string %q", s)
+	var value common.Hash
+	copy(value[:], s)
+	value[31] = byte(2 * len(s))
+	return value
+}
+
+// Mint credits account with amount of the mock token.
+func (c *Chain) Mint(t testing.TB, account common.Address, amount *big.Int) {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(mintABI))
+	require.NoError(t, err)
+	data, err := parsed.Pack("mint", account, amount)
+	require.NoError(t, err)
+	c.send(t, c.minter, &c.Token, data)
+}
+
+// Approve lets spender transfer up to amount of the mock token from the account of key.
+func (c *Chain) Approve(t testing.TB, key *ecdsa.PrivateKey, spender common.Address, amount *big.Int) {
+	t.Helper()
+	token, err := erc20.NewErc20Transactor(c.Token, c.Client)
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, c.ChainID)
+	require.NoError(t, err)
+	tx, err := token.Approve(opts, spender, amount)
+	require.NoError(t, err)
+	require.Equal(t, ethTypes.ReceiptStatusSuccessful, c.Receipt(t, tx.Hash()).Status)
+}
+
+// BalanceOf returns the mock token balance of account.
+func (c *Chain) BalanceOf(t testing.TB, account common.Address) *big.Int {
+	t.Helper()
+	token, err := eip3009.NewEip3009Caller(c.Token, c.Client)
+	require.NoError(t, err)
+	balance, err := token.BalanceOf(nil, account)
+	require.NoError(t, err)
+	return balance
+}
+
+// Receipt mines the pending transactions and returns the receipt of the transaction with hash.
+func (c *Chain) Receipt(t testing.TB, hash common.Hash) *ethTypes.Receipt {
+	t.Helper()
+	c.Backend.Commit()
+	receipt, err := c.Client.TransactionReceipt(context.Background(), hash)
+	require.NoError(t, err)
+	return receipt
+}
+
+// send sends a transaction of the account of key, a contract creation if to is nil, and mines it.
+func (c *Chain) send(t testing.TB, key *ecdsa.PrivateKey, to *common.Address, data []byte) *ethTypes.Receipt {
+	t.Helper()
+	ctx := context.Background()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := c.Client.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	head, err := c.Client.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	tx, err := ethTypes.SignNewTx(key, ethTypes.LatestSignerForChainID(c.ChainID), &ethTypes.DynamicFeeTx{
+		ChainID:   c.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), big.NewInt(params.GWei)),
+		Gas:       1_000_000,
+		To:        to,
+		Data:      data,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Client.SendTransaction(ctx, tx))
+	receipt := c.Receipt(t, tx.Hash())
+	require.Equal(t, ethTypes.ReceiptStatusSuccessful, receipt.Status)
+	return receipt
+}
@@ -0,0 +1,136 @@
+package evmtest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/eip3009"
+	"github.com/rabbitprincess/x402-facilitator/scheme/evm/permit2"
+)
+
+func TestToken(t *testing.T) {
+	key, err := crypto.HexToECDSA("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x209693Bc6afc0C5328bA36FaF03C514EF312287C")
+	chain := NewChain(t, from)
+	chain.Mint(t, from, big.NewInt(1000))
+	require.Equal(t, big.NewInt(1000), chain.BalanceOf(t, from))
+
+	// the domain read on-chain is the one of the registry
+	domain, err := evm.FetchDomain(t.Context(), chain.Client, chain.ChainID, chain.Token, "")
+	require.NoError(t, err)
+	expected := chain.Registry.GetDomainConfig(Network, chain.Token.Hex())
+	require.NoError(t, evm.CheckDomain(expected, domain))
+	require.Nil(t, evm.GetChainInfo(Network), "the chain is not registered globally")
+
+	// transfers with authorization move the balance once
+	authorization := evm.NewAuthorization(from.Hex(), to.Hex(), big.NewInt(400))
+	signature, err := evm.SignEip3009(authorization, expected, evm.NewRawPrivateSigner(crypto.FromECDSA(key)))
+	require.NoError(t, err)
+	sig, err := evm.ParseSignature(signature)
+	require.NoError(t, err)
+	token, err := eip3009.NewEip3009(chain.Token, chain.Client)
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chain.ChainID)
+	require.NoError(t, err)
+	transfer := func() error {
+		tx, err := token.TransferWithAuthorization(opts, authorization.From, authorization.To, authorization.Value,
+			authorization.ValidAfter, authorization.ValidBefore, authorization.Nonce, sig)
+		if err == nil {
+			chain.Receipt(t, tx.Hash())
+		}
+		return err
+	}
+	require.NoError(t, transfer())
+	require.Equal(t, big.NewInt(600), chain.BalanceOf(t, from))
+	require.Equal(t, big.NewInt(400), chain.BalanceOf(t, to))
+	require.Error(t, transfer(), "authorization reused")
+
+	// and only if signed by its sender
+	authorization.Nonce = evm.GenerateEIP3009Nonce()
+	require.Error(t, transfer(), "signature of another authorization")
+}
+
+func TestChainOf(t *testing.T) {
+	chain := NewChainOf(t, "base-sepolia")
+	expected := evm.GetDomainConfig("base-sepolia", "USDC")
+	require.Equal(t, expected.ChainID, chain.ChainID)
+	require.Equal(t, expected.VerifyingContract, chain.Token)
+
+	// the token has the domain of the network's USDC
+	domain, err := evm.FetchDomain(t.Context(), chain.Client, chain.ChainID, chain.Token, "")
+	require.NoError(t, err)
+	require.NoError(t, evm.CheckDomain(expected, domain))
+}
+
+func TestPermit2(t *testing.T) {
+	key, err := crypto.HexToECDSA("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+	spenderKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	spender := crypto.PubkeyToAddress(spenderKey.PublicKey)
+	to := common.HexToAddress("0x209693Bc6afc0C5328bA36FaF03C514EF312287C")
+	chain := NewChain(t, owner, spender)
+	chain.Mint(t, owner, big.NewInt(1000))
+	chain.Approve(t, key, evm.Permit2Address, big.NewInt(1000))
+
+	contract, err := permit2.NewPermit2(evm.Permit2Address, chain.Client)
+	require.NoError(t, err)
+	separator, err := contract.DOMAINSEPARATOR(nil)
+	require.NoError(t, err)
+	require.Equal(t, evm.Permit2DomainSeparator(chain.ChainID), separator[:])
+
+	nonce := evm.GenerateEIP3009Nonce()
+	permit := &evm.Permit{
+		Owner:    owner,
+		Token:    chain.Token,
+		Amount:   big.NewInt(500),
+		Spender:  spender,
+		Nonce:    new(big.Int).SetBytes(nonce[:]),
+		Deadline: big.NewInt(time.Now().Add(time.Hour).Unix()),
+		Witness:  evm.Witness{PayTo: to, Resource: "https://example.com/resource"},
+	}
+	signature, err := evm.SignPermit(permit, chain.ChainID, evm.NewRawPrivateSigner(crypto.FromECDSA(key)))
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(spenderKey, chain.ChainID)
+	require.NoError(t, err)
+	transfer := func(opts *bind.TransactOpts, amount int64, witness evm.Witness) error {
+		sig, err := evm.ParseSignature(signature)
+		require.NoError(t, err)
+		tx, err := contract.PermitWitnessTransferFrom(opts,
+			permit2.ISignatureTransferPermitTransferFrom{
+				Permitted: permit2.ISignatureTransferTokenPermissions{Token: permit.Token, Amount: permit.Amount},
+				Nonce:     permit.Nonce,
+				Deadline:  permit.Deadline,
+			},
+			permit2.ISignatureTransferSignatureTransferDetails{To: to, RequestedAmount: big.NewInt(amount)},
+			permit.Owner, [32]byte(witness.ToMessageHash()), evm.WitnessTypeString, sig)
+		if err == nil {
+			chain.Receipt(t, tx.Hash())
+		}
+		return err
+	}
+	require.Error(t, transfer(opts, 501, permit.Witness), "amount over the permitted one")
+	require.Error(t, transfer(opts, 300, evm.Witness{PayTo: spender, Resource: permit.Witness.Resource}), "witness of another recipient")
+	ownerOpts, err := bind.NewKeyedTransactorWithChainID(key, chain.ChainID)
+	require.NoError(t, err)
+	require.Error(t, transfer(ownerOpts, 300, permit.Witness), "permit granted to another spender")
+
+	// the permit transfers up to its amount once
+	require.NoError(t, transfer(opts, 300, permit.Witness))
+	require.Equal(t, big.NewInt(700), chain.BalanceOf(t, owner))
+	require.Equal(t, big.NewInt(300), chain.BalanceOf(t, to))
+	bitmap, err := contract.NonceBitmap(nil, owner, new(big.Int).Rsh(permit.Nonce, 8))
+	require.NoError(t, err)
+	require.NotZero(t, bitmap.Bit(int(new(big.Int).And(permit.Nonce, big.NewInt(0xff)).Int64())))
+	require.Error(t, transfer(opts, 100, permit.Witness), "nonce reused")
+}
@@ -0,0 +1,125 @@
+//go:build ignore
+
+// gen compiles each solidity source given as argument, such as token.sol, into the hex runtime code of
+// its only contract, such as token.bin, with solc of solcVersion, which must be on the PATH.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// solcVersion is the version of solc the sources are compiled with, for the same sources to give the same code.
+const solcVersion = "0.8.30"
+
+// evmVersion is the EVM version the sources are compiled for, supported by the simulated chain.
+const evmVersion = "cancun"
+
+func main() {
+	if err := checkVersion(); err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range os.Args[1:] {
+		code, err := compile(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(strings.TrimSuffix(name, ".sol")+".bin", []byte(code+"\n"), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// checkVersion checks that solc is of solcVersion.
+func checkVersion() error {
+	out, err := exec.Command("solc", "--version").Output()
+	if err != nil {
+		return fmt.Errorf("solc --version: %w", err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if version, ok := strings.CutPrefix(line, "Version: "); ok {
+			if !strings.HasPrefix(version, solcVersion+"+") {
+				return fmt.Errorf("solc is version %s, not %s", version, solcVersion)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("solc --version: no version in %q", out)
+}
+
+type input struct {
+	Language string                       `json:"language"`
+	Sources  map[string]map[string]string `json:"sources"`
+	Settings settings                     `json:"settings"`
+}
+
+type settings struct {
+	Optimizer       map[string]any                 `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+type output struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Contracts map[string]map[string]struct {
+		EVM struct {
+			DeployedBytecode struct {
+				Object string `json:"object"`
+			} `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// compile returns the hex runtime code of the only contract of the solidity source in file name.
+func compile(name string) (string, error) {
+	source, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(name)
+	in, err := json.Marshal(input{
+		Language: "Solidity",
+		Sources:  map[string]map[string]string{base: {"content": string(source)}},
+		Settings: settings{
+			Optimizer:       map[string]any{"enabled": true, "runs": 200},
+			EVMVersion:      evmVersion,
+			OutputSelection: map[string]map[string][]string{"*": {"*": {"evm.deployedBytecode.object"}}},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("solc", "--standard-json")
+	cmd.Stdin = bytes.NewReader(in)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("solc %s: %w", name, err)
+	}
+	var compiled output
+	if err := json.Unmarshal(out, &compiled); err != nil {
+		return "", fmt.Errorf("solc %s: %w", name, err)
+	}
+	for _, e := range compiled.Errors {
+		if e.Severity == "error" {
+			return "", fmt.Errorf("solc %s: %s", name, e.FormattedMessage)
+		}
+	}
+	contracts := compiled.Contracts[base]
+	if len(contracts) != 1 {
+		return "", fmt.Errorf("%s has %d contracts, not one", name, len(contracts))
+	}
+	for _, contract := range contracts {
+		return contract.EVM.DeployedBytecode.Object, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.30;
+
+// Permit2 is the mock Permit2 of NewChain, compiled into permit2.bin by gen.go. NewChain places its runtime
+// code at the address of Permit2, as the contract is deployed on every chain. Only signature transfers
+// with a witness are implemented, which the upto scheme settles with.
+contract Permit2 {
+    struct TokenPermissions {
+        address token;
+        uint256 amount;
+    }
+
+    struct PermitTransferFrom {
+        TokenPermissions permitted;
+        uint256 nonce;
+        uint256 deadline;
+    }
+
+    struct SignatureTransferDetails {
+        address to;
+        uint256 requestedAmount;
+    }
+
+    mapping(address => mapping(uint256 => uint256)) public nonceBitmap;
+
+    bytes32 private constant DOMAIN_TYPEHASH = keccak256("EIP712Domain(string name,uint256 chainId,address verifyingContract)");
+    bytes32 private constant TOKEN_PERMISSIONS_TYPEHASH = keccak256("TokenPermissions(address token,uint256 amount)");
+    string private constant PERMIT_WITNESS_TRANSFER_FROM_TYPEHASH_STUB =
+        "PermitWitnessTransferFrom(TokenPermissions permitted,address spender,uint256 nonce,uint256 deadline,";
+
+    function permitWitnessTransferFrom(
+        PermitTransferFrom calldata permit,
+        SignatureTransferDetails calldata transferDetails,
+        address owner,
+        bytes32 witness,
+        string calldata witnessTypeString,
+        bytes calldata signature
+    ) external {
+        require(block.timestamp <= permit.deadline);
+        require(transferDetails.requestedAmount <= permit.permitted.amount);
+        _useNonce(owner, permit.nonce);
+
+        // the signature is 65 bytes of r, s and v
+        require(signature.length == 65);
+        bytes32 digest = _hashPermit(permit, witness, witnessTypeString);
+        address signer = ecrecover(digest, uint8(signature[64]), bytes32(signature[0:32]), bytes32(signature[32:64]));
+        require(signer != address(0) && signer == owner);
+
+        _transferFrom(permit.permitted.token, owner, transferDetails.to, transferDetails.requestedAmount);
+    }
+
+    // _useNonce marks the unused nonce of owner used.
+    function _useNonce(address owner, uint256 nonce) private {
+        uint256 bit = 1 << (nonce & 0xff);
+        require(nonceBitmap[owner][nonce >> 8] & bit == 0);
+        nonceBitmap[owner][nonce >> 8] |= bit;
+    }
+
+    // _hashPermit returns the EIP-712 digest of the permit granted to the caller.
+    function _hashPermit(PermitTransferFrom calldata permit, bytes32 witness, string calldata witnessTypeString)
+        private
+        view
+        returns (bytes32)
+    {
+        bytes32 typeHash = keccak256(abi.encodePacked(PERMIT_WITNESS_TRANSFER_FROM_TYPEHASH_STUB, witnessTypeString));
+        bytes32 tokenPermissionsHash = keccak256(abi.encode(TOKEN_PERMISSIONS_TYPEHASH, permit.permitted));
+        bytes32 structHash =
+            keccak256(abi.encode(typeHash, tokenPermissionsHash, msg.sender, permit.nonce, permit.deadline, witness));
+        return keccak256(abi.encodePacked(hex"1901", DOMAIN_SEPARATOR(), structHash));
+    }
+
+    // _transferFrom transfers amount of token from owner to to, the token returning true or nothing.
+    function _transferFrom(address token, address owner, address to, uint256 amount) private {
+        (bool success, bytes memory data) =
+            token.call(abi.encodeWithSignature("transferFrom(address,address,uint256)", owner, to, amount));
+        require(success && (data.length == 0 || abi.decode(data, (bool))));
+    }
+
+    // DOMAIN_SEPARATOR returns the EIP-712 domain separator of Permit2, which has no version.
+    function DOMAIN_SEPARATOR() public view returns (bytes32) {
+        return keccak256(abi.encode(DOMAIN_TYPEHASH, keccak256("Permit2"), block.chainid, address(this)));
+    }
+}
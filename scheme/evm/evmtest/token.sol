@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.30;
+
+// Token is the mock EIP-3009 token of NewChain, compiled into token.bin by gen.go.
+//
+// The name and version of its EIP-712 domain are the short strings at slots 3 and 4, as FiatToken
+// stores them, set by NewChain in the genesis of the chain. mint is open to anyone, the token only
+// serves tests.
+contract Token {
+    mapping(address => uint256) public balanceOf;
+    mapping(address => mapping(bytes32 => bool)) public authorizationState;
+    mapping(address => mapping(address => uint256)) public allowance;
+    string public name;
+    string public version;
+
+    uint8 public constant decimals = 6;
+
+    bytes32 private constant DOMAIN_TYPEHASH =
+        keccak256("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)");
+    bytes32 private constant TRANSFER_WITH_AUTHORIZATION_TYPEHASH = keccak256(
+        "TransferWithAuthorization(address from,address to,uint256 value,uint256 validAfter,uint256 validBefore,bytes32 nonce)"
+    );
+
+    event Transfer(address indexed from, address indexed to, uint256 value);
+    event Approval(address indexed owner, address indexed spender, uint256 value);
+    event AuthorizationUsed(address indexed authorizer, bytes32 indexed nonce);
+
+    function mint(address to, uint256 value) external {
+        unchecked {
+            balanceOf[to] += value;
+        }
+        emit Transfer(address(0), to, value);
+    }
+
+    function approve(address spender, uint256 value) external returns (bool) {
+        allowance[msg.sender][spender] = value;
+        emit Approval(msg.sender, spender, value);
+        return true;
+    }
+
+    function transferFrom(address from, address to, uint256 value) external returns (bool) {
+        require(allowance[from][msg.sender] >= value);
+        allowance[from][msg.sender] -= value;
+        _transfer(from, to, value);
+        return true;
+    }
+
+    function transferWithAuthorization(
+        address from,
+        address to,
+        uint256 value,
+        uint256 validAfter,
+        uint256 validBefore,
+        bytes32 nonce,
+        bytes calldata signature
+    ) external {
+        require(block.timestamp > validAfter && block.timestamp < validBefore);
+        require(!authorizationState[from][nonce]);
+        authorizationState[from][nonce] = true;
+
+        bytes32 structHash =
+            keccak256(abi.encode(TRANSFER_WITH_AUTHORIZATION_TYPEHASH, from, to, value, validAfter, validBefore, nonce));
+        bytes32 digest = keccak256(abi.encodePacked(hex"1901", DOMAIN_SEPARATOR(), structHash));
+
+        // the signature is 65 bytes of r, s and v, v being 0 or 1 as well as 27 or 28
+        require(signature.length == 65);
+        uint8 v = uint8(signature[64]);
+        if (v < 27) {
+            v += 27;
+        }
+        address signer = ecrecover(digest, v, bytes32(signature[0:32]), bytes32(signature[32:64]));
+        require(signer != address(0) && signer == from);
+
+        emit AuthorizationUsed(from, nonce);
+        _transfer(from, to, value);
+    }
+
+    function DOMAIN_SEPARATOR() public view returns (bytes32) {
+        return keccak256(
+            abi.encode(DOMAIN_TYPEHASH, keccak256(bytes(name)), keccak256(bytes(version)), block.chainid, address(this))
+        );
+    }
+
+    // eip712Domain returns the EIP-712 domain of the token as EIP-5267 defines it, of its name, version,
+    // chain id and verifying contract.
+    function eip712Domain()
+        external
+        view
+        returns (bytes1, string memory, string memory, uint256, address, bytes32, uint256[] memory)
+    {
+        return (hex"0f", name, version, block.chainid, address(this), bytes32(0), new uint256[](0));
+    }
+
+    function _transfer(address from, address to, uint256 value) private {
+        require(balanceOf[from] >= value);
+        balanceOf[from] -= value;
+        unchecked {
+            balanceOf[to] += value;
+        }
+        emit Transfer(from, to, value);
+    }
+}
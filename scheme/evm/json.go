@@ -0,0 +1,154 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// authorizationJSON is the x402 wire format of an authorization, with amounts and times as
+// decimal strings and the nonce as 0x prefixed hex.
+type authorizationJSON struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Value       json.RawMessage `json:"value"`
+	ValidAfter  json.RawMessage `json:"validAfter"`
+	ValidBefore json.RawMessage `json:"validBefore"`
+	Nonce       json.RawMessage `json:"nonce"`
+}
+
+func (a Authorization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&authorizationJSON{
+		From:        a.From.Hex(),
+		To:          a.To.Hex(),
+		Value:       marshalDecimal(a.Value),
+		ValidAfter:  marshalDecimal(a.ValidAfter),
+		ValidBefore: marshalDecimal(a.ValidBefore),
+		Nonce:       json.RawMessage(fmt.Sprintf("%q", common.Hash(a.Nonce).Hex())),
+	})
+}
+
+// UnmarshalJSON decodes the x402 wire format, as well as numbers and a byte array nonce as sent by earlier versions.
+func (a *Authorization) UnmarshalJSON(data []byte) error {
+	var v authorizationJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	if a.From, err = unmarshalAddress(v.From); err != nil {
+		return fmt.Errorf("invalid from: %w", err)
+	}
+	if a.To, err = unmarshalAddress(v.To); err != nil {
+		return fmt.Errorf("invalid to: %w", err)
+	}
+	if a.Value, err = unmarshalDecimal(v.Value); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	if a.ValidAfter, err = unmarshalDecimal(v.ValidAfter); err != nil {
+		return fmt.Errorf("invalid validAfter: %w", err)
+	}
+	if a.ValidBefore, err = unmarshalDecimal(v.ValidBefore); err != nil {
+		return fmt.Errorf("invalid validBefore: %w", err)
+	}
+	var nonce common.Hash
+	if err := json.Unmarshal(v.Nonce, &nonce); err == nil {
+		a.Nonce = nonce
+	} else if err := json.Unmarshal(v.Nonce, &a.Nonce); err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	return nil
+}
+
+// permitJSON is the wire format of a permit, laid out as its typed data with amounts and times as decimal strings.
+type permitJSON struct {
+	Owner     string `json:"owner"`
+	Permitted struct {
+		Token  string          `json:"token"`
+		Amount json.RawMessage `json:"amount"`
+	} `json:"permitted"`
+	Spender  string          `json:"spender"`
+	Nonce    json.RawMessage `json:"nonce"`
+	Deadline json.RawMessage `json:"deadline"`
+	Witness  struct {
+		PayTo    string `json:"payTo"`
+		Resource string `json:"resource"`
+	} `json:"witness"`
+}
+
+func (p Permit) MarshalJSON() ([]byte, error) {
+	v := permitJSON{
+		Owner:    p.Owner.Hex(),
+		Spender:  p.Spender.Hex(),
+		Nonce:    marshalDecimal(p.Nonce),
+		Deadline: marshalDecimal(p.Deadline),
+	}
+	v.Permitted.Token = p.Token.Hex()
+	v.Permitted.Amount = marshalDecimal(p.Amount)
+	v.Witness.PayTo = p.Witness.PayTo.Hex()
+	v.Witness.Resource = p.Witness.Resource
+	return json.Marshal(&v)
+}
+
+func (p *Permit) UnmarshalJSON(data []byte) error {
+	var v permitJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	if p.Owner, err = unmarshalAddress(v.Owner); err != nil {
+		return fmt.Errorf("invalid owner: %w", err)
+	}
+	if p.Token, err = unmarshalAddress(v.Permitted.Token); err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	if p.Amount, err = unmarshalDecimal(v.Permitted.Amount); err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	if p.Spender, err = unmarshalAddress(v.Spender); err != nil {
+		return fmt.Errorf("invalid spender: %w", err)
+	}
+	if p.Nonce, err = unmarshalDecimal(v.Nonce); err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	if p.Deadline, err = unmarshalDecimal(v.Deadline); err != nil {
+		return fmt.Errorf("invalid deadline: %w", err)
+	}
+	if p.Witness.PayTo, err = unmarshalAddress(v.Witness.PayTo); err != nil {
+		return fmt.Errorf("invalid payTo: %w", err)
+	}
+	p.Witness.Resource = v.Witness.Resource
+	return nil
+}
+
+func marshalDecimal(n *big.Int) json.RawMessage {
+	if n == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(`"` + n.String() + `"`)
+}
+
+// unmarshalDecimal decodes a non-negative integer given as a decimal string or number, nil if absent.
+func unmarshalDecimal(raw json.RawMessage) (*big.Int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	n, ok := new(big.Int).SetString(strings.Trim(string(raw), `"`), 10)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("%s is not a non-negative integer", raw)
+	}
+	return n, nil
+}
+
+// unmarshalAddress parses a hex address in any case, the zero address if absent.
+func unmarshalAddress(s string) (common.Address, error) {
+	if s == "" {
+		return common.Address{}, nil
+	}
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("%s is not a hex address", s)
+	}
+	return common.HexToAddress(s), nil
+}
@@ -0,0 +1,89 @@
+package evm
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// the exact payload is the example payment of the x402 exact scheme specification for EVM, on base-sepolia.
+// The upto scheme has no reference payment, its golden payload is signed in the same format by the first
+// hardhat account.
+var (
+	referencePayer = common.HexToAddress("0x857b06519E91e3A54538791bDbb0E22373e36b66")
+	goldenPayer    = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+)
+
+func readGoldenPayload(t *testing.T, name string) *types.PaymentPayload {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	var payload types.PaymentPayload
+	require.NoError(t, json.Unmarshal(data, &payload))
+	require.Equal(t, int(types.X402VersionV1), payload.X402Version)
+	return &payload
+}
+
+func TestExactPayloadConformance(t *testing.T) {
+	payment := readGoldenPayload(t, "exact_payment_payload.json")
+
+	var payload EVMPayload
+	require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+	require.Equal(t, referencePayer, payload.Authorization.From)
+	require.Equal(t, "10000", payload.Authorization.Value.String())
+	require.Equal(t, "1740672154", payload.Authorization.ValidBefore.String())
+
+	// the payload encodes back to the golden payload
+	encoded, err := json.Marshal(&payload)
+	require.NoError(t, err)
+	require.JSONEq(t, string(payment.Payload), string(encoded))
+
+	// the signature recovers the authorizer
+	sig, err := ParseSignature(payload.Signature)
+	require.NoError(t, err)
+	signer, err := RecoverAddress(HashEip3009(payload.Authorization, GetDomainConfig(payment.Network, "USDC")), sig)
+	require.NoError(t, err)
+	require.Equal(t, referencePayer, signer)
+}
+
+func TestUptoPayloadConformance(t *testing.T) {
+	payment := readGoldenPayload(t, "upto_payment_payload.json")
+
+	var payload UptoPayload
+	require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+	require.Equal(t, goldenPayer, payload.Permit.Owner)
+	require.Equal(t, GetDomainConfig(payment.Network, "USDC").VerifyingContract, payload.Permit.Token)
+	require.Equal(t, "https://example.com/weather", payload.Permit.Witness.Resource)
+
+	encoded, err := json.Marshal(&payload)
+	require.NoError(t, err)
+	require.JSONEq(t, string(payment.Payload), string(encoded))
+
+	sig, err := ParseSignature(payload.Signature)
+	require.NoError(t, err)
+	signer, err := RecoverAddress(HashPermit(payload.Permit, GetChainID(payment.Network)), sig)
+	require.NoError(t, err)
+	require.Equal(t, goldenPayer, signer)
+}
+
+func TestAuthorizationLegacyJSON(t *testing.T) {
+	payment := readGoldenPayload(t, "exact_payment_payload.json")
+	var payload EVMPayload
+	require.NoError(t, json.Unmarshal(payment.Payload, &payload))
+
+	// earlier versions encoded amounts as numbers and the nonce as a byte array
+	nonce, err := json.Marshal(payload.Authorization.Nonce)
+	require.NoError(t, err)
+	legacy := `{"From":"0x857b06519e91e3a54538791bdbb0e22373e36b66","To":"0x209693bc6afc0c5328ba36faf03c514ef312287c",` +
+		`"Value":10000,"ValidAfter":1740672089,"ValidBefore":1740672154,"Nonce":` + string(nonce) + `}`
+	var authorization Authorization
+	require.NoError(t, json.Unmarshal([]byte(legacy), &authorization))
+	require.Equal(t, payload.Authorization, &authorization)
+
+	require.Error(t, json.Unmarshal([]byte(`{"value":"-1"}`), &authorization))
+	require.Error(t, json.Unmarshal([]byte(`{"from":"0xpayer"}`), &authorization))
+}
@@ -0,0 +1,138 @@
+package evm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rabbitprincess/x402-facilitator/types"
+)
+
+// Permit2Address is the address of the Permit2 contract, deployed at the same address on every chain.
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+// NewUptoPayload signs with the default registry, see Registry.NewUptoPayload.
+func NewUptoPayload(chain, token, owner, spender, payTo, resource string, maxValue string, signer types.Signer) (*UptoPayload, error) {
+	return DefaultRegistry.NewUptoPayload(chain, token, owner, spender, payTo, resource, maxValue, signer)
+}
+
+// NewUptoPayload signs a Permit2 transfer allowing spender to transfer up to maxValue of token from owner,
+// only to payTo and for resource, token being given by contract address or symbol of the registry.
+// The owner must have approved Permit2 to spend the token.
+func (r *Registry) NewUptoPayload(chain, token, owner, spender, payTo, resource string, maxValue string, signer types.Signer) (*UptoPayload, error) {
+	valueBig, ok := big.NewInt(0).SetString(maxValue, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid value: %s", maxValue)
+	}
+	domain := r.GetDomainConfig(chain, token)
+	if domain == nil {
+		return nil, fmt.Errorf("domain config not found for chain %s and token %s", chain, token)
+	}
+	// Permit2 nonces are unordered, any unused one is valid
+	nonce := GenerateEIP3009Nonce()
+	permit := &Permit{
+		Owner:    common.HexToAddress(owner),
+		Token:    domain.VerifyingContract,
+		Amount:   valueBig,
+		Spender:  common.HexToAddress(spender),
+		Nonce:    new(big.Int).SetBytes(nonce[:]),
+		Deadline: big.NewInt(time.Now().Unix() + 3600), // 1 hour
+		Witness: Witness{
+			PayTo:    common.HexToAddress(payTo),
+			Resource: resource,
+		},
+	}
+	signature, err := SignPermit(permit, domain.ChainID, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &UptoPayload{
+		Signature: signature,
+		Permit:    permit,
+	}, nil
+}
+
+// UptoPayload represents the payload for an upto EVM payment, a Permit2 transfer
+// allowing the facilitator to transfer up to a maximum amount to the signed recipient
+type UptoPayload struct {
+	Signature string  `json:"signature"`
+	Permit    *Permit `json:"permit"`
+}
+
+// Permit represents a Permit2 PermitWitnessTransferFrom EIP-712 typed data message signed by Owner,
+// allowing Spender to transfer up to Amount of Token once, to the recipient and for the resource of Witness.
+// It is encoded with amounts and times as decimal strings, see MarshalJSON.
+type Permit struct {
+	Owner    common.Address
+	Token    common.Address
+	Amount   *big.Int
+	Spender  common.Address
+	Nonce    *big.Int
+	Deadline *big.Int
+	Witness  Witness
+}
+
+// Witness is the payment signed along a permit, so that it only pays PayTo for Resource.
+type Witness struct {
+	PayTo    common.Address
+	Resource string
+}
+
+// WitnessTypeString completes the Permit2 PermitWitnessTransferFrom type with the witness, as passed to
+// permitWitnessTransferFrom.
+const WitnessTypeString = "Witness witness)TokenPermissions(address token,uint256 amount)Witness(address payTo,string resource)"
+
+var (
+	// Permit2 EIP-712 type hashes
+	PermitWitnessTransferFromTypeHash = Keccak256([]byte("PermitWitnessTransferFrom(TokenPermissions permitted,address spender,uint256 nonce,uint256 deadline," + WitnessTypeString))
+	TokenPermissionsTypeHash          = Keccak256([]byte("TokenPermissions(address token,uint256 amount)"))
+	WitnessTypeHash                   = Keccak256([]byte("Witness(address payTo,string resource)"))
+
+	// Permit2's domain has no version
+	permit2DomainTypeHash = Keccak256([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)"))
+)
+
+func (w Witness) ToMessageHash() []byte {
+	return Keccak256(WitnessTypeHash, padAddress(w.PayTo), Keccak256([]byte(w.Resource)))
+}
+
+func (p Permit) ToMessageHash() []byte {
+	encoded := bytes.Join([][]byte{
+		PermitWitnessTransferFromTypeHash,
+		Keccak256(TokenPermissionsTypeHash, padAddress(p.Token), padBigInt(p.Amount)),
+		padAddress(p.Spender),
+		padBigInt(p.Nonce),
+		padBigInt(p.Deadline),
+		p.Witness.ToMessageHash(),
+	}, nil)
+	return Keccak256(encoded)
+}
+
+// Permit2DomainSeparator returns the EIP-712 domain separator of Permit2 on the chain.
+func Permit2DomainSeparator(chainID *big.Int) []byte {
+	return Keccak256(permit2DomainTypeHash, Keccak256([]byte("Permit2")), padBigInt(chainID), padAddress(Permit2Address))
+}
+
+func SignPermit(permit *Permit, chainID *big.Int, signer types.Signer) (string, error) {
+	sig, err := signer(HashPermit(permit, chainID))
+	if err != nil {
+		return "", err
+	}
+	return EncodeSignature(sig), nil
+}
+
+func HashPermit(permit *Permit, chainID *big.Int) []byte {
+	return Keccak256([]byte{0x19, 0x01}, Permit2DomainSeparator(chainID), permit.ToMessageHash())
+}
+
+// RecoverAddress returns the address that signed digest.
+func RecoverAddress(digest, sig []byte) (common.Address, error) {
+	pubkey, err := Ecrecover(digest, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(Keccak256(pubkey[1:])[12:]), nil
+}
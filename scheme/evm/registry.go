@@ -0,0 +1,130 @@
+package evm
+
+import (
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Registry holds the chains by network name with the tokens accepted on each, starting with the built-in
+// chains and extended by Register. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	chainName map[int]string
+	chainInfo map[string]ChainInfo
+}
+
+// NewRegistry returns a registry of the built-in chains.
+func NewRegistry() *Registry {
+	return &Registry{chainName: maps.Clone(builtinChainName), chainInfo: maps.Clone(builtinChainInfo)}
+}
+
+// DefaultRegistry is the registry of the package-level lookups and Register.
+var DefaultRegistry = NewRegistry()
+
+// ChainConfig configures a chain of the registry by network name.
+// Chains and tokens of the built-in registry are overridden field by field, unset fields keeping their defaults.
+type ChainConfig struct {
+	ChainID    int64  `mapstructure:"chainId"`
+	DefaultUrl string `mapstructure:"defaultUrl"`
+	// Tokens accepted on the chain by symbol
+	Tokens map[string]TokenConfig `mapstructure:"tokens"`
+}
+
+// TokenConfig configures a token with the EIP-712 domain of its signatures.
+type TokenConfig struct {
+	Address  string `mapstructure:"address"`
+	Name     string `mapstructure:"name"`
+	Version  string `mapstructure:"version"`
+	Decimals uint8  `mapstructure:"decimals"`
+}
+
+// Register merges chains into the default registry, see Registry.Register.
+func Register(chains map[string]ChainConfig) error {
+	return DefaultRegistry.Register(chains)
+}
+
+// Register merges chains into the registry, adding new chains and tokens and overriding the built-in ones.
+// Either every chain is registered or, on error, none.
+func (r *Registry) Register(chains map[string]ChainConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := maps.Clone(r.chainName)
+	infos := maps.Clone(r.chainInfo)
+	for _, network := range slices.Sorted(maps.Keys(chains)) {
+		info, err := mergeChain(network, infos[network], chains[network])
+		if err != nil {
+			return fmt.Errorf("invalid chain %s: %w", network, err)
+		}
+		id := int(info.ChainID.Int64())
+		if name, ok := names[id]; ok && name != network {
+			return fmt.Errorf("invalid chain %s: chain id %d is registered as %s", network, id, name)
+		}
+		names[id] = network
+		infos[network] = info
+	}
+	r.chainName, r.chainInfo = names, infos
+	return nil
+}
+
+func mergeChain(network string, info ChainInfo, config ChainConfig) (ChainInfo, error) {
+	if config.ChainID != 0 {
+		if info.ChainID != nil && info.ChainID.Int64() != config.ChainID {
+			return info, fmt.Errorf("chain id %d differs from %s", config.ChainID, info.ChainID)
+		}
+		info.ChainID = big.NewInt(config.ChainID)
+	}
+	if info.ChainID == nil {
+		return info, fmt.Errorf("chain id is required")
+	}
+	if config.DefaultUrl != "" {
+		info.DefaultUrl = config.DefaultUrl
+	}
+
+	info.TokenContracts = maps.Clone(info.TokenContracts)
+	if info.TokenContracts == nil {
+		info.TokenContracts = make(map[string]TokenInfo)
+	}
+	for symbol, tokenConfig := range config.Tokens {
+		token, ok := info.TokenContracts[symbol]
+		if !ok {
+			if tokenConfig.Name == "" || tokenConfig.Version == "" || tokenConfig.Decimals == 0 {
+				return info, fmt.Errorf("token %s requires a name, version and decimals", symbol)
+			}
+		}
+		if tokenConfig.Address != "" {
+			if !common.IsHexAddress(tokenConfig.Address) {
+				return info, fmt.Errorf("invalid address of token %s: %s", symbol, tokenConfig.Address)
+			}
+			token.VerifyingContract = common.HexToAddress(tokenConfig.Address)
+		} else if !ok {
+			return info, fmt.Errorf("token %s requires an address", symbol)
+		}
+		if tokenConfig.Name != "" {
+			token.Name = tokenConfig.Name
+		}
+		if tokenConfig.Version != "" {
+			token.Version = tokenConfig.Version
+		}
+		if tokenConfig.Decimals != 0 {
+			token.Decimals = tokenConfig.Decimals
+		}
+		token.ChainID = info.ChainID
+		info.TokenContracts[symbol] = token
+	}
+
+	addresses := make(map[common.Address]string, len(info.TokenContracts))
+	for _, symbol := range slices.Sorted(maps.Keys(info.TokenContracts)) {
+		address := info.TokenContracts[symbol].VerifyingContract
+		if other, ok := addresses[address]; ok {
+			return info, fmt.Errorf("tokens %s and %s share the address %s", other, symbol, address.Hex())
+		}
+		addresses[address] = symbol
+	}
+	return info, nil
+}
@@ -0,0 +1,68 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	eurc := "0x808456652fdb597867f38412077A9182bf77359F"
+	err := r.Register(map[string]ChainConfig{
+		"base-sepolia": {
+			Tokens: map[string]TokenConfig{
+				"EURC": {Address: eurc, Name: "EURC", Version: "2", Decimals: 6},
+			},
+		},
+		"registry-l2": {
+			ChainID:    990001,
+			DefaultUrl: "https://rpc.registry-l2.example",
+			Tokens: map[string]TokenConfig{
+				"PYUSD": {Address: "0x0000000000000000000000000000000000990001", Name: "PayPal USD", Version: "1", Decimals: 6},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// tokens resolve by contract address and symbol, next to the built-in ones
+	symbol, token := r.GetToken("base-sepolia", eurc)
+	require.Equal(t, "EURC", symbol)
+	require.Equal(t, common.HexToAddress(eurc), token.VerifyingContract)
+	require.Equal(t, big.NewInt(84532), token.ChainID)
+	require.Equal(t, "EURC", r.GetDomainConfig("base-sepolia", "eurc").Name)
+	require.Equal(t, "USDC", r.GetDomainConfig("base-sepolia", "0x036CbD53842c5426634e7929541eC2318f3dCF7e").Name)
+	require.Nil(t, r.GetDomainConfig("base-sepolia", "0x0000000000000000000000000000000000000001"))
+
+	require.Equal(t, "registry-l2", r.GetChainName(big.NewInt(990001)))
+	require.Equal(t, "https://rpc.registry-l2.example", r.GetChainInfo("registry-l2").DefaultUrl)
+	require.Equal(t, uint8(6), r.GetTokens("registry-l2")["PYUSD"].Decimals)
+
+	// invalid chains leave the registry untouched
+	for name, chains := range map[string]map[string]ChainConfig{
+		"chain id changed":   {"base": {ChainID: 1}},
+		"chain id taken":     {"registry-other": {ChainID: 8453}},
+		"chain id missing":   {"registry-other": {}},
+		"token incomplete":   {"base": {Tokens: map[string]TokenConfig{"EURC": {Address: eurc}}}},
+		"address invalid":    {"base": {Tokens: map[string]TokenConfig{"EURC": {Address: "eurc", Name: "EURC", Version: "2", Decimals: 6}}}},
+		"address duplicated": {"base": {Tokens: map[string]TokenConfig{"USDC2": {Address: "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", Name: "USDC", Version: "2", Decimals: 6}}}},
+	} {
+		require.Error(t, r.Register(chains), name)
+	}
+	require.Nil(t, r.GetChainInfo("registry-other"))
+	require.Equal(t, "base", r.GetChainName(big.NewInt(8453)))
+
+	// tokens are overridden field by field
+	require.NoError(t, r.Register(map[string]ChainConfig{
+		"registry-l2": {Tokens: map[string]TokenConfig{"PYUSD": {Version: "2"}}},
+	}))
+	pyusd := r.GetTokens("registry-l2")["PYUSD"]
+	require.Equal(t, "2", pyusd.Version)
+	require.Equal(t, "PayPal USD", pyusd.Name)
+
+	// registries are independent of each other and of the default one
+	require.Nil(t, NewRegistry().GetChainInfo("registry-l2"))
+	require.Nil(t, GetChainInfo("registry-l2"))
+}
@@ -0,0 +1,145 @@
+package rpcpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// breakerState is the state of an endpoint's circuit breaker.
+type breakerState int
+
+const (
+	// stateClosed lets every call through.
+	stateClosed breakerState = iota
+	// stateOpen rejects calls until the open timeout elapses.
+	stateOpen
+	// stateHalfOpen lets a single trial call through to probe recovery.
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateClosed:
+		return "closed"
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ewmaWeight is the weight given to the latest latency sample.
+const ewmaWeight = 0.2
+
+// endpoint is a single RPC node with its health score and circuit breaker.
+type endpoint struct {
+	url    string
+	client *ethclient.Client
+
+	mu       sync.Mutex
+	latency  time.Duration // exponentially weighted moving average of successful calls
+	failures int           // consecutive transport failures
+	state    breakerState
+	openedAt time.Time
+	trial    bool // a half-open trial call is in flight
+}
+
+// allow reports whether a call may be sent to the endpoint and
+// moves an open breaker to half-open once the open timeout elapsed.
+func (e *endpoint) allow(now time.Time, openTimeout time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch e.state {
+	case stateOpen:
+		if now.Sub(e.openedAt) < openTimeout {
+			return false
+		}
+		e.state = stateHalfOpen
+		e.trial = true
+		return true
+	case stateHalfOpen:
+		if e.trial {
+			return false
+		}
+		e.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a successful call and closes the breaker.
+func (e *endpoint) success(latency time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(e.latency))
+	}
+	e.failures = 0
+	e.state = stateClosed
+	e.trial = false
+}
+
+// failure records a transport failure and opens the breaker once the threshold is reached.
+func (e *endpoint) failure(now time.Time, threshold int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.failures++
+	e.trial = false
+	if e.state == stateHalfOpen || e.failures >= threshold {
+		e.state = stateOpen
+		e.openedAt = now
+	}
+}
+
+// release gives back a half-open trial slot when the call ended without a verdict (e.g. canceled).
+func (e *endpoint) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.trial = false
+}
+
+// failurePenalty is the latency an endpoint is charged for each consecutive failure.
+const failurePenalty = time.Second
+
+// score ranks endpoints, lower is better.
+// Unmeasured endpoints rank first so that every node gets probed.
+func (e *endpoint) score() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	score := float64(e.latency + time.Duration(e.failures)*failurePenalty)
+	if e.state != stateClosed {
+		score += float64(time.Hour)
+	}
+	return score
+}
+
+// EndpointStatus is a snapshot of an endpoint's health.
+type EndpointStatus struct {
+	URL      string        `json:"url"`
+	State    string        `json:"state"`
+	Latency  time.Duration `json:"latency"`
+	Failures int           `json:"failures"`
+}
+
+func (e *endpoint) status() EndpointStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return EndpointStatus{
+		URL:      e.url,
+		State:    e.state.String(),
+		Latency:  e.latency,
+		Failures: e.failures,
+	}
+}
@@ -0,0 +1,371 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var _ bind.ContractBackend = (*Pool)(nil)
+
+// ErrNoEndpoint is returned when every endpoint's circuit is open.
+var ErrNoEndpoint = errors.New("no rpc endpoint available")
+
+const (
+	DefaultHedgeDelay       = 300 * time.Millisecond
+	DefaultFailureThreshold = 3
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// Config configures failover, hedging and circuit breaking of a Pool.
+type Config struct {
+	// Delay before a read is also sent to the next best endpoint, negative disables hedging
+	HedgeDelay time.Duration `mapstructure:"hedgeDelay"`
+	// Consecutive transport failures that open an endpoint's circuit
+	FailureThreshold int `mapstructure:"failureThreshold"`
+	// Time an open circuit rejects calls before letting a trial call through
+	OpenTimeout time.Duration `mapstructure:"openTimeout"`
+}
+
+func (c Config) withDefaults() Config {
+	if c.HedgeDelay == 0 {
+		c.HedgeDelay = DefaultHedgeDelay
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultFailureThreshold
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = DefaultOpenTimeout
+	}
+	return c
+}
+
+// Pool is a bind.ContractBackend spread over several RPC endpoints of the same chain.
+// Calls go to the healthiest endpoint and fail over to the next one on transport errors,
+// reads are hedged and endpoints that keep failing are taken out by a circuit breaker.
+type Pool struct {
+	endpoints []*endpoint
+	config    Config
+	now       func() time.Time
+}
+
+// Dial connects to every url. The urls must all serve the same chain, which every endpoint is asked for
+// so that failing over never moves calls to another chain.
+func Dial(ctx context.Context, urls []string, config Config) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("at least one rpc url must be provided")
+	}
+
+	pool := &Pool{
+		config: config.withDefaults(),
+		now:    time.Now,
+	}
+	var chainID *big.Int
+	for _, url := range urls {
+		client, err := ethclient.DialContext(ctx, url)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to dial %s: %w", url, err)
+		}
+		pool.endpoints = append(pool.endpoints, &endpoint{url: url, client: client})
+
+		id, err := client.ChainID(ctx)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to get chain ID of %s: %w", url, err)
+		}
+		if chainID == nil {
+			chainID = id
+		} else if id.Cmp(chainID) != 0 {
+			pool.Close()
+			return nil, fmt.Errorf("%s serves chain ID %s, not %s of %s", url, id, chainID, urls[0])
+		}
+	}
+	return pool, nil
+}
+
+// Close closes the connection to every endpoint.
+func (p *Pool) Close() {
+	for _, e := range p.endpoints {
+		e.client.Close()
+	}
+}
+
+// Status returns a snapshot of every endpoint's health.
+func (p *Pool) Status() []EndpointStatus {
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		status = append(status, e.status())
+	}
+	return status
+}
+
+// ordered returns the endpoints from the best score to the worst.
+func (p *Pool) ordered() []*endpoint {
+	type scored struct {
+		endpoint *endpoint
+		score    float64
+	}
+	candidates := make([]scored, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		candidates = append(candidates, scored{endpoint: e, score: e.score()})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score < candidates[j].score
+	})
+
+	ordered := make([]*endpoint, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c.endpoint)
+	}
+	return ordered
+}
+
+// clientErrors are raised by the client whatever the endpoint, such as subscribing over http,
+// so retrying them on another endpoint would fail alike.
+var clientErrors = []error{
+	context.Canceled,
+	ethereum.NotFound,
+	rpc.ErrNotificationsUnsupported,
+	rpc.ErrSubscriptionNotFound,
+	rpc.ErrClientQuit,
+}
+
+// isEndpointError reports whether err is caused by the endpoint itself (unreachable,
+// overloaded, timed out) and the call may be retried on another endpoint.
+// Errors returned by a healthy node, such as a reverted call, and errors of the client are final.
+func isEndpointError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, clientErr := range clientErrors {
+		if errors.Is(err, clientErr) {
+			return false
+		}
+	}
+	var marshalErr *json.MarshalerError
+	var typeErr *json.UnsupportedTypeError
+	var valueErr *json.UnsupportedValueError
+	if errors.As(err, &marshalErr) || errors.As(err, &typeErr) || errors.As(err, &valueErr) {
+		// the arguments of the call cannot be encoded
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429 || httpErr.StatusCode >= 500
+	}
+	return true
+}
+
+// isKnownTransaction reports whether a node rejected a transaction because it already has it.
+func isKnownTransaction(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+type callFn[T any] func(ctx context.Context, client *ethclient.Client) (T, error)
+
+// attempt runs fn on a single endpoint and updates its health.
+func attempt[T any](ctx context.Context, p *Pool, e *endpoint, fn callFn[T]) (T, error) {
+	start := p.now()
+	res, err := fn(ctx, e.client)
+	switch {
+	case err == nil || (ctx.Err() == nil && !isEndpointError(err)):
+		// the node answered, even if with an error
+		e.success(p.now().Sub(start))
+	case ctx.Err() != nil:
+		e.release()
+	default:
+		e.failure(p.now(), p.config.FailureThreshold)
+	}
+	return res, err
+}
+
+// failover runs fn on the best endpoint and moves to the next one on endpoint errors.
+func failover[T any](ctx context.Context, p *Pool, fn callFn[T]) (T, error) {
+	var zero T
+	lastErr := ErrNoEndpoint
+	for _, e := range p.ordered() {
+		if !e.allow(p.now(), p.config.OpenTimeout) {
+			continue
+		}
+		res, err := attempt(ctx, p, e, fn)
+		if !isEndpointError(err) || ctx.Err() != nil {
+			return res, err
+		}
+		lastErr = err
+	}
+	return zero, lastErr
+}
+
+// hedge runs fn on the best endpoint and, if it has not answered within the hedge delay
+// or failed, on the next one too. The first final answer wins and cancels the others.
+func hedge[T any](ctx context.Context, p *Pool, fn callFn[T]) (T, error) {
+	if p.config.HedgeDelay < 0 {
+		return failover(ctx, p, fn)
+	}
+
+	var zero T
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		res T
+		err error
+	}
+	candidates := p.ordered()
+	results := make(chan result, len(candidates))
+	next, inflight := 0, 0
+	launch := func() bool {
+		for next < len(candidates) {
+			e := candidates[next]
+			next++
+			if !e.allow(p.now(), p.config.OpenTimeout) {
+				continue
+			}
+			inflight++
+			go func() {
+				res, err := attempt(ctx, p, e, fn)
+				results <- result{res: res, err: err}
+			}()
+			return true
+		}
+		return false
+	}
+
+	if !launch() {
+		return zero, ErrNoEndpoint
+	}
+	timer := time.NewTimer(p.config.HedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for inflight > 0 {
+		select {
+		case r := <-results:
+			inflight--
+			if !isEndpointError(r.err) {
+				return r.res, r.err
+			}
+			lastErr = r.err
+			launch()
+		case <-timer.C:
+			if launch() {
+				timer.Reset(p.config.HedgeDelay)
+			}
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	return zero, lastErr
+}
+
+// Reads are hedged, anything depending on a node's local view (pending state, gas)
+// or with side effects only fails over.
+
+func (p *Pool) ChainID(ctx context.Context) (*big.Int, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) (*big.Int, error) {
+		return c.ChainID(ctx)
+	})
+}
+
+func (p *Pool) NetworkID(ctx context.Context) (*big.Int, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) (*big.Int, error) {
+		return c.NetworkID(ctx)
+	})
+}
+
+func (p *Pool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) ([]byte, error) {
+		return c.CodeAt(ctx, contract, blockNumber)
+	})
+}
+
+func (p *Pool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) ([]byte, error) {
+		return c.CallContract(ctx, call, blockNumber)
+	})
+}
+
+func (p *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) (*ethTypes.Header, error) {
+		return c.HeaderByNumber(ctx, number)
+	})
+}
+
+func (p *Pool) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) ([]ethTypes.Log, error) {
+		return c.FilterLogs(ctx, query)
+	})
+}
+
+func (p *Pool) TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error) {
+	return hedge(ctx, p, func(ctx context.Context, c *ethclient.Client) (*ethTypes.Receipt, error) {
+		return c.TransactionReceipt(ctx, txHash)
+	})
+}
+
+func (p *Pool) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (uint64, error) {
+		return c.EstimateGas(ctx, call)
+	})
+}
+
+func (p *Pool) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (*big.Int, error) {
+		return c.SuggestGasPrice(ctx)
+	})
+}
+
+func (p *Pool) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (*big.Int, error) {
+		return c.SuggestGasTipCap(ctx)
+	})
+}
+
+func (p *Pool) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) ([]byte, error) {
+		return c.PendingCodeAt(ctx, account)
+	})
+}
+
+func (p *Pool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (uint64, error) {
+		return c.PendingNonceAt(ctx, account)
+	})
+}
+
+// SendTransaction broadcasts tx, failing over to the next endpoint on transport errors.
+// Resending the same signed transaction is harmless, so a node that already knows it counts as success.
+func (p *Pool) SendTransaction(ctx context.Context, tx *ethTypes.Transaction) error {
+	_, err := failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (struct{}, error) {
+		err := c.SendTransaction(ctx, tx)
+		if err != nil && isKnownTransaction(err) {
+			err = nil
+		}
+		return struct{}{}, err
+	})
+	return err
+}
+
+func (p *Pool) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- ethTypes.Log) (ethereum.Subscription, error) {
+	return failover(ctx, p, func(ctx context.Context, c *ethclient.Client) (ethereum.Subscription, error) {
+		return c.SubscribeFilterLogs(ctx, query, ch)
+	})
+}
@@ -0,0 +1,162 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// newRPCServer serves eth_chainId, optionally failing or answering after a delay.
+func newRPCServer(t *testing.T, chainID int, delay time.Duration, fail *atomic.Bool, calls *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail != nil && fail.Load() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, req.ID, chainID)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDialChecksChainID(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+	sepolia := newRPCServer(t, 84532, 0, nil, &calls)
+	mainnet := newRPCServer(t, 8453, 0, nil, &calls)
+	down := newRPCServer(t, 84532, 0, &fail, &calls)
+	fail.Store(true)
+
+	// every url must serve the same chain
+	_, err := Dial(t.Context(), []string{sepolia.URL, mainnet.URL}, Config{})
+	require.ErrorContains(t, err, "serves chain ID 8453, not 84532")
+	_, err = Dial(t.Context(), []string{sepolia.URL, down.URL}, Config{})
+	require.ErrorContains(t, err, "failed to get chain ID")
+
+	pool, err := Dial(t.Context(), []string{sepolia.URL, sepolia.URL}, Config{})
+	require.NoError(t, err)
+	pool.Close()
+}
+
+func TestPoolFailover(t *testing.T) {
+	var fail atomic.Bool
+	var badCalls, goodCalls atomic.Int32
+	bad := newRPCServer(t, 84532, 0, &fail, &badCalls)
+	good := newRPCServer(t, 84532, 0, nil, &goodCalls)
+
+	pool, err := Dial(t.Context(), []string{bad.URL, good.URL}, Config{
+		HedgeDelay:       -1,
+		FailureThreshold: 1,
+		OpenTimeout:      time.Hour,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+	fail.Store(true)
+	badCalls.Store(0)
+
+	// every call succeeds through the healthy endpoint
+	for i := 0; i < 5; i++ {
+		chainID, err := pool.ChainID(t.Context())
+		require.NoError(t, err)
+		require.EqualValues(t, 84532, chainID.Int64())
+	}
+	// the failing endpoint is ranked down then taken out by the circuit breaker
+	require.EqualValues(t, 1, badCalls.Load())
+	require.Equal(t, "open", pool.Status()[0].State)
+	require.Equal(t, "closed", pool.Status()[1].State)
+}
+
+func TestPoolClientErrorsAreFinal(t *testing.T) {
+	var calls atomic.Int32
+	first := newRPCServer(t, 84532, 0, nil, &calls)
+	second := newRPCServer(t, 84532, 0, nil, &calls)
+
+	pool, err := Dial(t.Context(), []string{first.URL, second.URL}, Config{
+		HedgeDelay:       -1,
+		FailureThreshold: 1,
+		OpenTimeout:      time.Hour,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// subscriptions are unsupported over http on every endpoint, which stay healthy
+	_, err = pool.SubscribeFilterLogs(t.Context(), ethereum.FilterQuery{}, make(chan ethTypes.Log))
+	require.ErrorIs(t, err, rpc.ErrNotificationsUnsupported)
+	require.Equal(t, "closed", pool.Status()[0].State)
+	require.Equal(t, "closed", pool.Status()[1].State)
+
+	require.False(t, isEndpointError(fmt.Errorf("call: %w", rpc.ErrClientQuit)))
+	require.False(t, isEndpointError(&json.UnsupportedValueError{Str: "NaN"}))
+	require.True(t, isEndpointError(context.DeadlineExceeded))
+}
+
+func TestPoolCircuitRecovers(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+	srv := newRPCServer(t, 1, 0, &fail, &calls)
+
+	pool, err := Dial(t.Context(), []string{srv.URL}, Config{
+		HedgeDelay:       -1,
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+	fail.Store(true)
+	calls.Store(0)
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	_, err = pool.ChainID(t.Context())
+	require.Error(t, err)
+	_, err = pool.ChainID(t.Context())
+	require.ErrorIs(t, err, ErrNoEndpoint)
+	require.EqualValues(t, 1, calls.Load())
+
+	// after the open timeout a trial call closes the circuit again
+	fail.Store(false)
+	now = now.Add(2 * time.Minute)
+	_, err = pool.ChainID(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "closed", pool.Status()[0].State)
+}
+
+func TestPoolHedging(t *testing.T) {
+	var slowCalls, fastCalls atomic.Int32
+	slow := newRPCServer(t, 10, 2*time.Second, nil, &slowCalls)
+	fast := newRPCServer(t, 10, 0, nil, &fastCalls)
+
+	pool, err := Dial(t.Context(), []string{slow.URL, fast.URL}, Config{HedgeDelay: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer pool.Close()
+	fastCalls.Store(0)
+
+	start := time.Now()
+	chainID, err := pool.ChainID(t.Context())
+	require.NoError(t, err)
+	require.EqualValues(t, 10, chainID.Int64())
+	require.Less(t, time.Since(start), time.Second, "hedged call must not wait for the slow endpoint")
+	require.EqualValues(t, 1, fastCalls.Load())
+}
@@ -1,6 +1,7 @@
 package evm
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -46,7 +47,16 @@ func SignEip3009(auth *Authorization, domain *DomainConfig, signer types.Signer)
 	if err != nil {
 		return "", err
 	}
-	return hex.EncodeToString(sig), nil
+	return EncodeSignature(sig), nil
+}
+
+// EncodeSignature encodes a [R || S || V] signature as 0x prefixed hex with V being 27 or 28, as signed by wallets.
+func EncodeSignature(sig []byte) string {
+	encoded := bytes.Clone(sig)
+	if len(encoded) == SignatureLength && encoded[RecoveryIDOffset] < 27 {
+		encoded[RecoveryIDOffset] += 27
+	}
+	return "0x" + hex.EncodeToString(encoded)
 }
 
 func HashEip3009(auth *Authorization, domain *DomainConfig) []byte {
@@ -1,10 +1,11 @@
 package evm
 
 import (
-	"encoding/hex"
 	"testing"
 
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,7 +48,7 @@ func TestPayloadSignVerify(t *testing.T) {
 		"0x1234567890abcdef1234567890abcdef12345678", "0xabcdefabcdefabcdefabcdefabcdefabcdefabcdef", "100", signer)
 	require.NoError(t, err)
 	message := HashEip3009(payload.Authorization, GetDomainConfig(chain, token))
-	signature, err := hex.DecodeString(payload.Signature)
+	signature, err := ParseSignature(payload.Signature)
 	require.NoError(t, err)
 	pubkey, err := Ecrecover(message, signature)
 	require.NoError(t, err)
@@ -56,3 +57,79 @@ func TestPayloadSignVerify(t *testing.T) {
 	valid := VerifySignature(pubkey, message, signature[:64])
 	require.True(t, valid, "signature verification failed")
 }
+
+func TestUptoPayloadSignVerify(t *testing.T) {
+	// Generate a random private key
+	privKey, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+	owner, err := GetAddrssFromPrivateKey(privKey.Serialize())
+	require.NoError(t, err)
+
+	// Create a signer using the private key
+	signer := NewRawPrivateSigner(privKey.Serialize())
+
+	chain := "base-sepolia"
+	token := "USDC"
+	domain := GetDomainConfig(chain, token)
+
+	payload, err := NewUptoPayload(chain, token, owner.Hex(), "0xabcdefabcdefabcdefabcdefabcdefabcdefabcdef",
+		"0x209693Bc6afc0C5328bA36FaF03C514EF312287C", "https://example.com/resource", "1000", signer)
+	require.NoError(t, err)
+	require.Equal(t, domain.VerifyingContract, payload.Permit.Token)
+
+	// The digest matches the EIP-712 typed data hash of the Permit2 transfer with its witness
+	permit := payload.Permit
+	expected, _, err := apitypes.TypedDataAndHash(apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"PermitWitnessTransferFrom": {
+				{Name: "permitted", Type: "TokenPermissions"},
+				{Name: "spender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+				{Name: "witness", Type: "Witness"},
+			},
+			"TokenPermissions": {
+				{Name: "token", Type: "address"},
+				{Name: "amount", Type: "uint256"},
+			},
+			"Witness": {
+				{Name: "payTo", Type: "address"},
+				{Name: "resource", Type: "string"},
+			},
+		},
+		PrimaryType: "PermitWitnessTransferFrom",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Permit2",
+			ChainId:           (*math.HexOrDecimal256)(domain.ChainID),
+			VerifyingContract: Permit2Address.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"permitted": map[string]interface{}{
+				"token":  permit.Token.Hex(),
+				"amount": permit.Amount.String(),
+			},
+			"spender":  permit.Spender.Hex(),
+			"nonce":    permit.Nonce.String(),
+			"deadline": permit.Deadline.String(),
+			"witness": map[string]interface{}{
+				"payTo":    permit.Witness.PayTo.Hex(),
+				"resource": permit.Witness.Resource,
+			},
+		},
+	})
+	require.NoError(t, err)
+	message := HashPermit(permit, domain.ChainID)
+	require.Equal(t, expected, message)
+
+	// The owner signed the permit
+	signature, err := ParseSignature(payload.Signature)
+	require.NoError(t, err)
+	recovered, err := RecoverAddress(message, signature)
+	require.NoError(t, err)
+	require.Equal(t, owner, recovered)
+}
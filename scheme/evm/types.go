@@ -17,13 +17,20 @@ import (
 	"github.com/rabbitprincess/x402-facilitator/types"
 )
 
+// NewEVMPayload signs with the default registry, see Registry.NewEVMPayload.
 func NewEVMPayload(chain, token, from, to string, value string, signer types.Signer) (*EVMPayload, error) {
+	return DefaultRegistry.NewEVMPayload(chain, token, from, to, value, signer)
+}
+
+// NewEVMPayload signs an EIP-3009 authorization of value of token on chain from from to to, token being
+// given by contract address or symbol of the registry.
+func (r *Registry) NewEVMPayload(chain, token, from, to string, value string, signer types.Signer) (*EVMPayload, error) {
 	valueBig, ok := big.NewInt(0).SetString(value, 10)
 	if !ok {
 		return nil, fmt.Errorf("invalid value: %s", value)
 	}
 	authorization := NewAuthorization(from, to, valueBig)
-	domain := GetDomainConfig(chain, token)
+	domain := r.GetDomainConfig(chain, token)
 	if domain == nil {
 		return nil, fmt.Errorf("domain config not found for chain %s and token %s", chain, token)
 	}
@@ -59,7 +66,8 @@ func NewAuthorization(from, to string, value *big.Int) *Authorization {
 }
 
 // TransferWithAuthorization represents the payload for an EIP-3009
-// authorization EIP-712 typed data message
+// authorization EIP-712 typed data message.
+// It is encoded in the x402 wire format, see MarshalJSON.
 type Authorization struct {
 	From        common.Address
 	To          common.Address
@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for every span created by the facilitator.
+const TracerName = "github.com/rabbitprincess/x402-facilitator"
+
+// Attribute keys shared between the HTTP layer and the facilitators.
+const (
+	AttrRequestID     = attribute.Key("x402.request_id")
+	AttrScheme        = attribute.Key("x402.scheme")
+	AttrNetwork       = attribute.Key("x402.network")
+	AttrPayer         = attribute.Key("x402.payer")
+	AttrIsValid       = attribute.Key("x402.is_valid")
+	AttrInvalidReason = attribute.Key("x402.invalid_reason")
+	AttrTxHash        = attribute.Key("x402.tx_hash")
+	AttrRPCMethod     = attribute.Key("rpc.method")
+)
+
+type Config struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`    // OTLP/HTTP collector endpoint, e.g. "localhost:4318"
+	Insecure    bool    `mapstructure:"insecure"`    // Use plain HTTP to reach the collector
+	ServiceName string  `mapstructure:"serviceName"` // Defaults to "x402-facilitator"
+	SampleRatio float64 `mapstructure:"sampleRatio"` // Ratio of root traces to sample, defaults to 1
+}
+
+// Tracer returns the tracer used across the facilitator.
+// It resolves the global provider on each call so that Setup can be invoked at any time.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Setup installs the W3C trace context propagator and, when enabled, a global
+// tracer provider exporting spans over OTLP/HTTP.
+// The returned function flushes and stops the provider.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	provider := NewTracerProvider(config, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider builds a tracer provider configured with the service resource and sampler
+// described by config. Extra options such as span processors are appended as is.
+func NewTracerProvider(config Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "x402-facilitator"
+	}
+	ratio := config.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// RecordError marks the span as failed with the given error.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -2,14 +2,41 @@ package types
 
 import "errors"
 
+// Error reasons of the x402 specification, returned as invalidReason by /verify and errorReason by /settle.
 var (
-	ErrInvalidPayloadFormat = errors.New("invalid_payload_format")
-	ErrIncompatibleScheme   = errors.New("incompatible_payload_scheme")
-	ErrNetworkMismatch      = errors.New("network_mismatch")
-	ErrInvalidNetwork       = errors.New("invalid_network")
-	ErrNetworkIDMismatch    = errors.New("network_id_mismatch")
-	ErrInvalidSignature     = errors.New("invalid_signature")
-	ErrInvalidToken         = errors.New("invalid_token")
-	ErrTokenMismatch        = errors.New("token_mismatch")
-	ErrInsufficientBalance  = errors.New("insufficient_balance")
+	ErrInsufficientFunds                              = errors.New("insufficient_funds")
+	ErrInvalidExactEvmPayloadAuthorizationValidAfter  = errors.New("invalid_exact_evm_payload_authorization_valid_after")
+	ErrInvalidExactEvmPayloadAuthorizationValidBefore = errors.New("invalid_exact_evm_payload_authorization_valid_before")
+	ErrInvalidExactEvmPayloadAuthorizationValue       = errors.New("invalid_exact_evm_payload_authorization_value")
+	ErrInvalidExactEvmPayloadSignature                = errors.New("invalid_exact_evm_payload_signature")
+	ErrInvalidExactEvmPayloadRecipientMismatch        = errors.New("invalid_exact_evm_payload_recipient_mismatch")
+	ErrInvalidNetwork                                 = errors.New("invalid_network")
+	ErrInvalidPayload                                 = errors.New("invalid_payload")
+	ErrInvalidPaymentRequirements                     = errors.New("invalid_payment_requirements")
+	ErrInvalidScheme                                  = errors.New("invalid_scheme")
+	ErrUnsupportedScheme                              = errors.New("unsupported_scheme")
+	ErrInvalidX402Version                             = errors.New("invalid_x402_version")
+	ErrInvalidTransactionState                        = errors.New("invalid_transaction_state")
+	ErrUnexpectedVerifyError                          = errors.New("unexpected_verify_error")
+	ErrUnexpectedSettleError                          = errors.New("unexpected_settle_error")
+)
+
+// Error reasons of the upto scheme, named like the reasons of the exact scheme.
+var (
+	ErrInvalidUptoEvmPayloadPermitSpender     = errors.New("invalid_upto_evm_payload_permit_spender")
+	ErrInvalidUptoEvmPayloadPermitDeadline    = errors.New("invalid_upto_evm_payload_permit_deadline")
+	ErrInvalidUptoEvmPayloadPermitNonce       = errors.New("invalid_upto_evm_payload_permit_nonce")
+	ErrInvalidUptoEvmPayloadPermitValue       = errors.New("invalid_upto_evm_payload_permit_value")
+	ErrInvalidUptoEvmPayloadPermitToken       = errors.New("invalid_upto_evm_payload_permit_token")
+	ErrInvalidUptoEvmPayloadPermitAllowance   = errors.New("invalid_upto_evm_payload_permit_allowance")
+	ErrInvalidUptoEvmPayloadRecipientMismatch = errors.New("invalid_upto_evm_payload_recipient_mismatch")
+	ErrInvalidUptoEvmPayloadResourceMismatch  = errors.New("invalid_upto_evm_payload_resource_mismatch")
+	ErrInvalidUptoEvmPayloadSignature         = errors.New("invalid_upto_evm_payload_signature")
+	ErrInvalidUptoEvmSettleAmount             = errors.New("invalid_upto_evm_settle_amount")
+)
+
+// Error reasons of facilitator features beyond the specification.
+var (
+	ErrResourceAlreadyPaid = errors.New("resource_already_paid")
+	ErrPolicyViolation     = errors.New("policy_violation")
 )
@@ -13,6 +13,9 @@ type PaymentRequirements struct {
 	Network string `json:"network"`
 	// Maximum amount required to pay for the resource in atomic units
 	MaxAmountRequired string `json:"maxAmountRequired"`
+	// Amount to settle in atomic units under the upto scheme, at most MaxAmountRequired.
+	// Only set when settling, MaxAmountRequired is settled if empty
+	Amount string `json:"amount,omitempty"`
 	// URL of the resource to pay for
 	Resource string `json:"resource"`
 	// Description of the resource
@@ -31,6 +34,23 @@ type PaymentRequirements struct {
 	Extra *json.RawMessage `json:"extra,omitempty"`
 }
 
+// SettleAmount returns the amount to settle in atomic units.
+func (r *PaymentRequirements) SettleAmount() string {
+	if r.Amount != "" {
+		return r.Amount
+	}
+	return r.MaxAmountRequired
+}
+
+// PaymentRequiredResponse is the body of a 402 Payment Required response of a resource server,
+// listing the payments it accepts.
+type PaymentRequiredResponse struct {
+	X402Version int `json:"x402Version"`
+	// Reason the request was not served, such as a missing or invalid payment
+	Error   string                `json:"error"`
+	Accepts []PaymentRequirements `json:"accepts"`
+}
+
 // PaymentPayload represents the data the client sends in the X-PAYMENT header.
 type PaymentPayload struct {
 	// Version of the x402 payment protocol
@@ -44,12 +64,18 @@ type PaymentPayload struct {
 }
 
 // PaymentVerifyRequest is the request body sent to facilitator's /verify endpoint.
+// The payment is also accepted as a base64 encoded X-PAYMENT header in paymentHeader.
 type PaymentVerifyRequest struct {
 	X402Version         int                 `json:"x402Version"`
-	PaymentHeader       PaymentPayload      `json:"paymentHeader"`
+	PaymentPayload      PaymentPayload      `json:"paymentPayload"`
 	PaymentRequirements PaymentRequirements `json:"paymentRequirements"`
 }
 
+func (r *PaymentVerifyRequest) UnmarshalJSON(data []byte) error {
+	type request PaymentVerifyRequest
+	return unmarshalPaymentRequest(data, (*request)(r), &r.PaymentPayload)
+}
+
 // PaymentVerifyResponse is the response returned from the /verify endpoint.
 type PaymentVerifyResponse struct {
 	// Whether the payment payload is valid
@@ -57,32 +83,100 @@ type PaymentVerifyResponse struct {
 	// Error message or reason for invalidity, if applicable
 	InvalidReason string `json:"invalidReason,omitempty"`
 	Payer         string `json:"payer,omitempty"`
+	// Previous settlement of a one-time resource, set when the payer already paid for it
+	Receipt *PaymentReceipt `json:"receipt,omitempty"`
 }
 
 // PaymentSettleRequest is the request body sent to facilitator's /settle endpoint.
+// The payment is also accepted as a base64 encoded X-PAYMENT header in paymentHeader.
 type PaymentSettleRequest struct {
 	X402Version         int                 `json:"x402Version"`
-	PaymentHeader       PaymentPayload      `json:"paymentHeader"`
+	PaymentPayload      PaymentPayload      `json:"paymentPayload"`
 	PaymentRequirements PaymentRequirements `json:"paymentRequirements"`
 }
 
+func (r *PaymentSettleRequest) UnmarshalJSON(data []byte) error {
+	type request PaymentSettleRequest
+	return unmarshalPaymentRequest(data, (*request)(r), &r.PaymentPayload)
+}
+
+// unmarshalPaymentRequest decodes a verify or settle request, taking its payment from paymentHeader
+// if there is no paymentPayload. paymentHeader is either a base64 encoded X-PAYMENT header or,
+// as sent by earlier versions of this facilitator, the payment payload object.
+func unmarshalPaymentRequest(data []byte, request any, payload *PaymentPayload) error {
+	if err := json.Unmarshal(data, request); err != nil {
+		return err
+	}
+	var fields struct {
+		PaymentPayload json.RawMessage `json:"paymentPayload"`
+		PaymentHeader  json.RawMessage `json:"paymentHeader"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if hasValue(fields.PaymentPayload) || !hasValue(fields.PaymentHeader) {
+		return nil
+	}
+
+	var header string
+	if err := json.Unmarshal(fields.PaymentHeader, &header); err != nil {
+		return json.Unmarshal(fields.PaymentHeader, payload)
+	}
+	decoded, err := DecodePaymentHeader(header)
+	if err != nil {
+		return err
+	}
+	*payload = *decoded
+	return nil
+}
+
+func hasValue(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
+
 // PaymentSettleResponse is the response from the /settle endpoint.
 type PaymentSettleResponse struct {
 	// Whether the payment was successful
 	Success bool `json:"success"`
-	// Error message, if any
-	Error string `json:"error,omitempty"`
+	// Reason of the failure, if any
+	ErrorReason string `json:"errorReason,omitempty"`
+	// Address of the payer
+	Payer string `json:"payer,omitempty"`
 	// Transaction hash of the settled payment
-	TxHash string `json:"txHash,omitempty"`
-	// Network ID where the transaction was submitted
-	NetworkId string `json:"networkId,omitempty"`
+	Transaction string `json:"transaction"`
+	// Network where the transaction was submitted
+	Network string `json:"network"`
+	// Amount transferred in atomic units
+	Amount string `json:"amount,omitempty"`
 }
 
 // SupportedKind represents a supported scheme and network pair
 // used in the /supported endpoint.
 type SupportedKind struct {
-	Scheme  string `json:"scheme"`
-	Network string `json:"network"`
+	// Version of the x402 protocol the kind is served with
+	X402Version int    `json:"x402Version"`
+	Scheme      string `json:"scheme"`
+	Network     string `json:"network"`
+	// Extra information clients need to pay with the scheme, such as the spender of upto permits
+	Extra *json.RawMessage `json:"extra,omitempty"`
+	// Address of the facilitator paying the gas of settlements
+	FeePayer string `json:"feePayer,omitempty"`
+	// Assets accepted on the network
+	Assets []SupportedAsset `json:"assets,omitempty"`
+}
+
+// SupportedAsset describes an asset accepted by a supported kind, with what resource servers
+// need to build their payment requirements.
+type SupportedAsset struct {
+	// Address of the token contract, the asset of payment requirements
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+	// EIP-712 domain name and version of the token, the extra of payment requirements
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Minimum amount in atomic units a payment must be worth, covering the gas of its settlement
+	MinAmountRequired string `json:"minAmountRequired,omitempty"`
 }
 
 // SupportedResponse is the response structure returned from the /supported endpoint.
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// HeaderPayment is the request header carrying the base64 encoded PaymentPayload of a paid request
+	HeaderPayment = "X-PAYMENT"
+	// HeaderPaymentResponse is the response header carrying the base64 encoded PaymentSettleResponse of a paid request
+	HeaderPaymentResponse = "X-PAYMENT-RESPONSE"
+)
+
+// EncodePaymentHeader encodes payload as the value of the X-PAYMENT header.
+func EncodePaymentHeader(payload *PaymentPayload) (string, error) {
+	return encodeHeader(payload)
+}
+
+// DecodePaymentHeader decodes the value of an X-PAYMENT header.
+func DecodePaymentHeader(header string) (*PaymentPayload, error) {
+	var payload PaymentPayload
+	if err := decodeHeader(header, &payload); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", HeaderPayment, err)
+	}
+	return &payload, nil
+}
+
+// EncodePaymentResponseHeader encodes res as the value of the X-PAYMENT-RESPONSE header.
+func EncodePaymentResponseHeader(res *PaymentSettleResponse) (string, error) {
+	return encodeHeader(res)
+}
+
+// DecodePaymentResponseHeader decodes the value of an X-PAYMENT-RESPONSE header.
+func DecodePaymentResponseHeader(header string) (*PaymentSettleResponse, error) {
+	var res PaymentSettleResponse
+	if err := decodeHeader(header, &res); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", HeaderPaymentResponse, err)
+	}
+	return &res, nil
+}
+
+func encodeHeader(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodeHeader accepts the standard base64 encoding of the specification as well as its url-safe and unpadded variants.
+func decodeHeader(header string, v any) error {
+	header = strings.TrimSpace(header)
+	var data []byte
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err = encoding.DecodeString(header); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
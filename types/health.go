@@ -0,0 +1,35 @@
+package types
+
+// HealthStatus values reported by the /healthz and /readyz endpoints.
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck is the result of a single readiness probe (e.g. rpc, chain_id, signer, block).
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Error message or reason for failure, if applicable
+	Error string `json:"error,omitempty"`
+}
+
+// NetworkHealth is the readiness breakdown of a single network served by the facilitator.
+type NetworkHealth struct {
+	Family  string `json:"family"`
+	Network string `json:"network"`
+	Healthy bool   `json:"healthy"`
+	// Chain ID reported by the upstream RPC node
+	ChainID string `json:"chainId,omitempty"`
+	// Latest block number and its age in seconds
+	LatestBlock    uint64 `json:"latestBlock,omitempty"`
+	LatestBlockAge int64  `json:"latestBlockAge,omitempty"`
+	// Individual probes run against the network
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthResponse is the response returned from the /healthz and /readyz endpoints.
+type HealthResponse struct {
+	Status   string           `json:"status"`
+	Networks []*NetworkHealth `json:"networks,omitempty"`
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PaymentStatus is the state of a payment in the ledger.
+type PaymentStatus string
+
+const (
+	// PaymentStatusVerified payments passed verification and were not settled yet
+	PaymentStatusVerified PaymentStatus = "verified"
+	// PaymentStatusInvalid payments failed verification
+	PaymentStatusInvalid PaymentStatus = "invalid"
+	// PaymentStatusSettled payments were transferred on chain
+	PaymentStatusSettled PaymentStatus = "settled"
+	// PaymentStatusFailed payments could not be settled
+	PaymentStatusFailed PaymentStatus = "failed"
+)
+
+// Payment is a payment recorded in the ledger, from its first verification to its settlement.
+type Payment struct {
+	// Identifier derived from the payment payload
+	ID     string        `json:"id"`
+	Status PaymentStatus `json:"status"`
+
+	Scheme   string `json:"scheme"`
+	Network  string `json:"network"`
+	Payer    string `json:"payer,omitempty"`
+	PayTo    string `json:"payTo"`
+	Asset    string `json:"asset"`
+	Amount   string `json:"amount"`
+	Resource string `json:"resource"`
+
+	// Reason of the last failed verification
+	InvalidReason string `json:"invalidReason,omitempty"`
+	// Transaction hash of the settlement
+	TxHash string `json:"txHash,omitempty"`
+	// Error of the last failed settlement
+	Error string `json:"error,omitempty"`
+
+	// Request and tenant of the last update
+	RequestID string `json:"requestId,omitempty"`
+	TenantID  string `json:"tenantId,omitempty"`
+
+	Payload      json.RawMessage `json:"payload" swaggertype:"object"`
+	Requirements json.RawMessage `json:"requirements" swaggertype:"object"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Status transitions, oldest first
+	Events []PaymentEvent `json:"events,omitempty"`
+}
+
+// PaymentEvent is a verification or settlement attempt of a payment.
+type PaymentEvent struct {
+	Status    PaymentStatus `json:"status"`
+	Reason    string        `json:"reason,omitempty"`
+	TxHash    string        `json:"txHash,omitempty"`
+	RequestID string        `json:"requestId,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// PaymentListResponse is a page of payments returned from the /payments endpoint.
+type PaymentListResponse struct {
+	Payments []*Payment `json:"payments"`
+	// Cursor of the next page, empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// PaymentReceipt is the settlement of a resource by a payer.
+type PaymentReceipt struct {
+	Resource string    `json:"resource"`
+	Payer    string    `json:"payer"`
+	Network  string    `json:"network"`
+	TxHash   string    `json:"txHash"`
+	PaidAt   time.Time `json:"paidAt"`
+}
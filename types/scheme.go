@@ -1,18 +1,40 @@
 package types
 
+import "slices"
+
+// Family is the family of blockchains a network belongs to, which determines how payloads are signed and settled.
+type Family string
+
+const (
+	EVM    Family = "evm"
+	Solana Family = "solana"
+	Sui    Family = "sui"
+	Tron   Family = "tron"
+)
+
+// Scheme is the x402 payment scheme, which determines how the amount of a payment is settled.
 type Scheme string
 
 const (
-	EVM    Scheme = "evm"
-	Solana Scheme = "solana"
-	Sui    Scheme = "sui"
-	Tron   Scheme = "tron"
+	// SchemeExact transfers exactly the required amount
+	SchemeExact Scheme = "exact"
+	// SchemeUpto authorizes a maximum amount, of which the resource server settles the amount actually used
+	SchemeUpto Scheme = "upto"
 )
 
+// X402Version is the version of the x402 protocol a payment is made with.
 type X402Version int
 
 const (
 	X402VersionV1 X402Version = 1
 )
 
+// X402Versions are the protocol versions whose requests and payloads are understood, oldest first.
+var X402Versions = []X402Version{X402VersionV1}
+
+// Valid reports whether v is one of X402Versions.
+func (v X402Version) Valid() bool {
+	return slices.Contains(X402Versions, v)
+}
+
 type Signer func(digest []byte) (signature []byte, err error)
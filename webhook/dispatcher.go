@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Dispatcher delivers events to the registered endpoints in the background,
+// retrying failed deliveries with exponential backoff and keeping the ones
+// that exhausted their attempts in a dead-letter list for replay.
+// Endpoints registered through the API and dead letters are persisted in the store
+// and cached in memory.
+type Dispatcher struct {
+	config Config
+	store  Store
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	endpoints   []*Endpoint
+	deadLetters []*DeadLetter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher registers the configured endpoints and loads the stored endpoints and dead letters.
+// A nil store keeps them in memory.
+func NewDispatcher(ctx context.Context, config Config, store Store) (*Dispatcher, error) {
+	config = config.withDefaults()
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	for _, endpoint := range config.Endpoints {
+		if endpoint.ID == "" {
+			// configured endpoints keep their ID across restarts, which their dead letters refer to
+			sum := sha256.Sum256([]byte(endpoint.URL))
+			endpoint.ID = "wh_" + hex.EncodeToString(sum[:16])
+		}
+		if err := endpoint.validate(ctx, config.AllowPrivateNetworks); err != nil {
+			return nil, err
+		}
+	}
+	stored, err := store.Endpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook endpoints: %w", err)
+	}
+	deadLetters, err := store.DeadLetters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+
+	dispatchCtx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		config:      config,
+		store:       store,
+		client:      newClient(config),
+		now:         time.Now,
+		endpoints:   append(slices.Clone(config.Endpoints), stored...),
+		deadLetters: deadLetters,
+		ctx:         dispatchCtx,
+		cancel:      cancel,
+	}
+	ids := make(map[string]bool)
+	for _, endpoint := range d.endpoints {
+		if ids[endpoint.ID] {
+			cancel()
+			return nil, fmt.Errorf("webhook endpoint %s already exists", endpoint.ID)
+		}
+		ids[endpoint.ID] = true
+	}
+	return d, nil
+}
+
+// Close stops pending retries and waits for in-flight deliveries.
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Register adds and stores an endpoint, generating its ID and signing secret if they are not set.
+// Tenants register up to Config.MaxEndpoints endpoints.
+func (d *Dispatcher) Register(ctx context.Context, endpoint *Endpoint) (*Endpoint, error) {
+	if err := endpoint.validate(ctx, d.config.AllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if slices.ContainsFunc(d.endpoints, func(e *Endpoint) bool { return e.ID == endpoint.ID }) {
+		return nil, fmt.Errorf("webhook endpoint %s already exists", endpoint.ID)
+	}
+	count := 0
+	for _, e := range d.endpoints {
+		if e.TenantID == endpoint.TenantID {
+			count++
+		}
+	}
+	if count >= d.config.MaxEndpoints {
+		return nil, ErrTooManyEndpoints
+	}
+	if err := d.store.PutEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to store webhook endpoint: %w", err)
+	}
+	d.endpoints = append(d.endpoints, endpoint)
+	return endpoint, nil
+}
+
+// Unregister removes an endpoint of the tenant, an empty tenantID removes any endpoint.
+func (d *Dispatcher) Unregister(ctx context.Context, id, tenantID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, endpoint := range d.endpoints {
+		if endpoint.ID == id && owned(endpoint.TenantID, tenantID) {
+			if err := d.store.DeleteEndpoint(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+			}
+			d.endpoints = slices.Delete(d.endpoints, i, i+1)
+			return nil
+		}
+	}
+	return ErrEndpointNotFound
+}
+
+// Endpoints returns the tenant's endpoints without their secrets, an empty tenantID returns every endpoint.
+func (d *Dispatcher) Endpoints(tenantID string) []*Endpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	endpoints := []*Endpoint{}
+	for _, endpoint := range d.endpoints {
+		if owned(endpoint.TenantID, tenantID) {
+			e := *endpoint
+			e.Secret = ""
+			endpoints = append(endpoints, &e)
+		}
+	}
+	return endpoints
+}
+
+// DeadLetters returns the failed deliveries to the tenant's endpoints, an empty tenantID returns every one.
+func (d *Dispatcher) DeadLetters(tenantID string) []*DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deadLetters := []*DeadLetter{}
+	for _, deadLetter := range d.deadLetters {
+		if owned(deadLetter.TenantID, tenantID) {
+			deadLetters = append(deadLetters, deadLetter)
+		}
+	}
+	return deadLetters
+}
+
+// Replay removes a dead letter and delivers its event again with a fresh set of attempts.
+func (d *Dispatcher) Replay(ctx context.Context, id, tenantID string) error {
+	d.mu.Lock()
+	i := slices.IndexFunc(d.deadLetters, func(deadLetter *DeadLetter) bool {
+		return deadLetter.ID == id && owned(deadLetter.TenantID, tenantID)
+	})
+	if i < 0 {
+		d.mu.Unlock()
+		return ErrDeadLetterNotFound
+	}
+	deadLetter := d.deadLetters[i]
+	endpointIdx := slices.IndexFunc(d.endpoints, func(e *Endpoint) bool { return e.ID == deadLetter.EndpointID })
+	if endpointIdx < 0 {
+		d.mu.Unlock()
+		return ErrEndpointNotFound
+	}
+	endpoint := d.endpoints[endpointIdx]
+	if err := d.store.DeleteDeadLetter(ctx, id); err != nil {
+		d.mu.Unlock()
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	d.deadLetters = slices.Delete(d.deadLetters, i, i+1)
+	d.mu.Unlock()
+
+	d.deliverAsync(endpoint, deadLetter.Event)
+	return nil
+}
+
+// Publish delivers event to every endpoint subscribed to it.
+func (d *Dispatcher) Publish(event *Event) {
+	d.mu.Lock()
+	var endpoints []*Endpoint
+	for _, endpoint := range d.endpoints {
+		if endpoint.matches(event) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, endpoint := range endpoints {
+		d.deliverAsync(endpoint, event)
+	}
+}
+
+func (d *Dispatcher) deliverAsync(endpoint *Endpoint, event *Event) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.deliver(endpoint, event)
+	}()
+}
+
+// deliver posts event to endpoint until it succeeds or runs out of attempts.
+func (d *Dispatcher) deliver(endpoint *Endpoint, event *Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("event_id", event.ID).Msg("Failed to encode webhook event")
+		return
+	}
+
+	var lastErr error
+	attempt := 1
+	for ; attempt <= d.config.MaxAttempts; attempt++ {
+		if lastErr = d.post(endpoint, event, body); lastErr == nil {
+			return
+		}
+		if attempt == d.config.MaxAttempts {
+			break
+		}
+		select {
+		case <-time.After(d.config.backoff(attempt)):
+		case <-d.ctx.Done():
+			lastErr = fmt.Errorf("dispatcher closed: %w", lastErr)
+		}
+		if d.ctx.Err() != nil {
+			break
+		}
+	}
+
+	log.Warn().Err(lastErr).Str("event_id", event.ID).Str("endpoint_id", endpoint.ID).Int("attempts", attempt).
+		Msg("Webhook delivery failed, moved to dead letters")
+	d.addDeadLetter(&DeadLetter{
+		ID:         randomID("dl_"),
+		EndpointID: endpoint.ID,
+		TenantID:   endpoint.TenantID,
+		Event:      event,
+		Attempts:   attempt,
+		LastError:  lastErr.Error(),
+		FailedAt:   d.now().UTC(),
+	})
+}
+
+// addDeadLetter stores a dead letter, dropping the oldest ones beyond Config.MaxDeadLetters.
+func (d *Dispatcher) addDeadLetter(deadLetter *DeadLetter) {
+	// stored even while closing, the delivery would otherwise be lost
+	ctx := context.Background()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.store.PutDeadLetter(ctx, deadLetter); err != nil {
+		log.Error().Err(err).Str("event_id", deadLetter.Event.ID).Msg("Failed to store dead letter")
+	}
+	d.deadLetters = append(d.deadLetters, deadLetter)
+	for len(d.deadLetters) > d.config.MaxDeadLetters {
+		oldest := d.deadLetters[0]
+		if err := d.store.DeleteDeadLetter(ctx, oldest.ID); err != nil {
+			log.Error().Err(err).Str("event_id", oldest.Event.ID).Msg("Failed to delete dead letter")
+		}
+		log.Warn().Str("event_id", oldest.Event.ID).Str("endpoint_id", oldest.EndpointID).Msg("Dropped oldest dead letter")
+		d.deadLetters = d.deadLetters[1:]
+	}
+}
+
+func (d *Dispatcher) post(endpoint *Endpoint, event *Event, body []byte) error {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, event.Type)
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, body, d.now()))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// owned reports whether a resource of owner is visible to tenantID, an empty tenantID sees everything.
+func owned(owner, tenantID string) bool {
+	return tenantID == "" || owner == tenantID
+}
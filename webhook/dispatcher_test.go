@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a webhook endpoint failing the first failures deliveries
+type receiver struct {
+	*httptest.Server
+	failures   atomic.Int32
+	calls      atomic.Int32
+	deliveries chan delivery
+}
+
+// delivery is a request accepted by a receiver, checked by the test
+type delivery struct {
+	eventID string
+	body    []byte
+	err     error
+}
+
+func newReceiver(t *testing.T, secret string, failures int32) *receiver {
+	r := &receiver{deliveries: make(chan delivery, 16)}
+	r.failures.Store(failures)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.calls.Add(1)
+		body, err := io.ReadAll(req.Body)
+		if err == nil {
+			if err := VerifySignature(secret, req.Header.Get(HeaderSignature), body, DefaultTolerance, time.Now()); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		if r.failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		r.deliveries <- delivery{eventID: req.Header.Get(HeaderEventID), body: body, err: err}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) next(t *testing.T) *Event {
+	select {
+	case d := <-r.deliveries:
+		require.NoError(t, d.err)
+		var event Event
+		require.NoError(t, json.Unmarshal(d.body, &event))
+		require.Equal(t, event.ID, d.eventID)
+		return &event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook delivered")
+		return nil
+	}
+}
+
+func newTestDispatcher(t *testing.T, store Store, endpoints ...*Endpoint) *Dispatcher {
+	d, err := NewDispatcher(t.Context(), Config{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		MaxEndpoints:         2,
+		MaxDeadLetters:       2,
+		AllowPrivateNetworks: true,
+		Endpoints:            endpoints,
+	}, store)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+	return d
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	merchant := newReceiver(t, "merchant-secret", 2)
+	other := newReceiver(t, "other-secret", 0)
+	d := newTestDispatcher(t, nil,
+		&Endpoint{URL: merchant.URL, Secret: "merchant-secret", PayTo: []string{"0xMerchant"}},
+		&Endpoint{URL: other.URL, Secret: "other-secret", PayTo: []string{"0xother"}, Events: []string{EventPaymentFailed}},
+	)
+
+	d.Publish(NewEvent(EventPaymentSettled, EventData{PayTo: "0xmerchant", TxHash: "0xtx"}))
+	event := merchant.next(t)
+	require.Equal(t, EventPaymentSettled, event.Type)
+	require.Equal(t, "0xtx", event.Data.TxHash)
+	require.EqualValues(t, 3, merchant.calls.Load(), "delivered on the last attempt")
+
+	// endpoints only receive their payTo addresses and event types
+	d.Publish(NewEvent(EventPaymentSettled, EventData{PayTo: "0xother"}))
+	d.wg.Wait()
+	require.Zero(t, other.calls.Load())
+	require.Empty(t, d.DeadLetters(""))
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	r := newReceiver(t, "secret", 3)
+	store := NewMemoryStore()
+	d := newTestDispatcher(t, store)
+	_, err := d.Register(t.Context(), &Endpoint{URL: r.URL, Secret: "secret", TenantID: "merchant"})
+	require.NoError(t, err)
+
+	d.Publish(NewEvent(EventPaymentFailed, EventData{TenantID: "merchant", Reason: "insufficient_funds"}))
+	require.Eventually(t, func() bool { return len(d.DeadLetters("merchant")) == 1 }, 5*time.Second, time.Millisecond)
+	deadLetter := d.DeadLetters("merchant")[0]
+	require.Equal(t, 3, deadLetter.Attempts)
+	require.Contains(t, deadLetter.LastError, "status 503")
+
+	// dead letters are scoped to the endpoint's tenant
+	require.Empty(t, d.DeadLetters("other"))
+	require.ErrorIs(t, d.Replay(t.Context(), deadLetter.ID, "other"), ErrDeadLetterNotFound)
+
+	// endpoints and dead letters are reloaded from the store
+	d.Close()
+	d = newTestDispatcher(t, store)
+	require.Len(t, d.Endpoints("merchant"), 1)
+	require.Equal(t, []*DeadLetter{deadLetter}, d.DeadLetters("merchant"))
+
+	// the receiver recovered
+	require.NoError(t, d.Replay(t.Context(), deadLetter.ID, "merchant"))
+	event := r.next(t)
+	require.Equal(t, deadLetter.Event.ID, event.ID)
+	require.Equal(t, "insufficient_funds", event.Data.Reason)
+	require.Empty(t, d.DeadLetters("merchant"))
+	stored, err := store.DeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, stored)
+}
+
+func TestDispatcherLimits(t *testing.T) {
+	d := newTestDispatcher(t, nil)
+	for range 2 {
+		_, err := d.Register(t.Context(), &Endpoint{URL: "https://merchant.example.com/webhook", TenantID: "merchant"})
+		require.NoError(t, err)
+	}
+	_, err := d.Register(t.Context(), &Endpoint{URL: "https://merchant.example.com/webhook", TenantID: "merchant"})
+	require.ErrorIs(t, err, ErrTooManyEndpoints)
+	_, err = d.Register(t.Context(), &Endpoint{URL: "https://other.example.com/webhook", TenantID: "other"})
+	require.NoError(t, err)
+
+	// the oldest dead letters are dropped
+	for i := range 3 {
+		d.addDeadLetter(&DeadLetter{ID: fmt.Sprintf("dl_%d", i), Event: NewEvent(EventPaymentFailed, EventData{})})
+	}
+	deadLetters := d.DeadLetters("")
+	require.Len(t, deadLetters, 2)
+	require.Equal(t, "dl_1", deadLetters[0].ID)
+}
+
+func TestPrivateEndpoints(t *testing.T) {
+	d, err := NewDispatcher(t.Context(), Config{}, nil)
+	require.NoError(t, err)
+	defer d.Close()
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/webhook",
+		"http://localhost/webhook",
+		"http://[::1]/webhook",
+		"http://10.0.0.1/webhook",
+		"http://192.168.1.1/webhook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fd00:ec2::254]/webhook",
+		"http://metadata.google.internal/computeMetadata/v1",
+		"http://0.0.0.0/webhook",
+	} {
+		_, err := d.Register(t.Context(), &Endpoint{URL: url})
+		require.ErrorIs(t, err, ErrForbiddenAddress, url)
+	}
+
+	// hosts resolving to private addresses after registration are refused when dialing
+	r := newReceiver(t, "secret", 0)
+	err = d.post(&Endpoint{URL: r.URL, Secret: "secret"}, NewEvent(EventPaymentSettled, EventData{}), []byte(`{}`))
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	require.Zero(t, r.calls.Load())
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+	header := Sign("secret", body, now)
+
+	require.NoError(t, VerifySignature("secret", header, body, DefaultTolerance, now))
+	require.ErrorIs(t, VerifySignature("wrong", header, body, DefaultTolerance, now), ErrInvalidSignature)
+	require.ErrorIs(t, VerifySignature("secret", header, []byte(`{"id":"evt_2"}`), DefaultTolerance, now), ErrInvalidSignature)
+	require.ErrorIs(t, VerifySignature("secret", header, body, DefaultTolerance, now.Add(time.Hour)), ErrInvalidSignature)
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for endpoints on loopback, private, link-local or metadata addresses.
+var ErrForbiddenAddress = errors.New("webhook endpoint address is not public")
+
+// forbiddenHosts are names of the host itself and of cloud metadata services.
+var forbiddenHosts = []string{"localhost", "metadata", "metadata.google.internal", "instance-data"}
+
+// sharedAddressSpace is the carrier-grade NAT range, private to providers.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether addr is routable on the internet, excluding loopback, private,
+// link-local (such as the 169.254.169.254 metadata service), multicast and unspecified addresses.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// checkHost rejects endpoint hosts that are not public, resolving host names.
+func checkHost(ctx context.Context, host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, forbidden := range forbiddenHosts {
+		if host == forbidden || strings.HasSuffix(host, "."+forbidden) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr)
+		}
+	}
+	return nil
+}
+
+// newClient returns the client delivering events. Unless private networks are allowed, the address of every
+// connection, including redirects and hosts resolving differently than at registration, is checked before dialing.
+func newClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &http.Transport{
+			// a proxy would dial the endpoint on our behalf, bypassing the check
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: config.Timeout,
+			MaxIdleConnsPerHost: 4,
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Store persists the endpoints registered through the API and the dead letters,
+// so that they survive restarts. Endpoints configured at startup are not stored.
+type Store interface {
+	// Endpoints returns the stored endpoints in registration order.
+	Endpoints(ctx context.Context) ([]*Endpoint, error)
+	PutEndpoint(ctx context.Context, endpoint *Endpoint) error
+	DeleteEndpoint(ctx context.Context, id string) error
+	// DeadLetters returns the stored dead letters, oldest first.
+	DeadLetters(ctx context.Context) ([]*DeadLetter, error)
+	PutDeadLetter(ctx context.Context, deadLetter *DeadLetter) error
+	DeleteDeadLetter(ctx context.Context, id string) error
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps endpoints and dead letters in process memory.
+type MemoryStore struct {
+	mu          sync.Mutex
+	endpoints   []*Endpoint
+	deadLetters []*DeadLetter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Endpoints(ctx context.Context) ([]*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.endpoints), nil
+}
+
+func (s *MemoryStore) PutEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = append(s.endpoints, endpoint)
+	return nil
+}
+
+func (s *MemoryStore) DeleteEndpoint(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = slices.DeleteFunc(s.endpoints, func(e *Endpoint) bool { return e.ID == id })
+	return nil
+}
+
+func (s *MemoryStore) DeadLetters(ctx context.Context) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.deadLetters), nil
+}
+
+func (s *MemoryStore) PutDeadLetter(ctx context.Context, deadLetter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters = append(s.deadLetters, deadLetter)
+	return nil
+}
+
+func (s *MemoryStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters = slices.DeleteFunc(s.deadLetters, func(d *DeadLetter) bool { return d.ID == id })
+	return nil
+}
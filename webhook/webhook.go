@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event types delivered to webhook endpoints.
+const (
+	EventPaymentVerified = "payment.verified"
+	EventPaymentSettled  = "payment.settled"
+	EventPaymentFailed   = "payment.failed"
+)
+
+// EventTypes lists every event type an endpoint can subscribe to.
+var EventTypes = []string{EventPaymentVerified, EventPaymentSettled, EventPaymentFailed}
+
+var (
+	ErrEndpointNotFound   = errors.New("webhook endpoint not found")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrTooManyEndpoints   = errors.New("too many webhook endpoints")
+)
+
+const (
+	DefaultMaxAttempts    = 8
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 10 * time.Minute
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxEndpoints   = 20
+	DefaultMaxDeadLetters = 1000
+)
+
+// Config configures webhook delivery.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Deliveries of an event before it is moved to the dead-letter list
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// Delay before the first retry, doubled on each further retry up to MaxBackoff
+	InitialBackoff time.Duration `mapstructure:"initialBackoff"`
+	MaxBackoff     time.Duration `mapstructure:"maxBackoff"`
+	// Timeout of a single delivery
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Endpoints registered per tenant through the API
+	MaxEndpoints int `mapstructure:"maxEndpoints"`
+	// Dead letters kept, the oldest being dropped beyond
+	MaxDeadLetters int `mapstructure:"maxDeadLetters"`
+	// Deliver to loopback, private and link-local addresses, which are refused by default
+	AllowPrivateNetworks bool `mapstructure:"allowPrivateNetworks"`
+	// Endpoints registered at startup
+	Endpoints []*Endpoint `mapstructure:"endpoints"`
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = DefaultInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.MaxEndpoints <= 0 {
+		c.MaxEndpoints = DefaultMaxEndpoints
+	}
+	if c.MaxDeadLetters <= 0 {
+		c.MaxDeadLetters = DefaultMaxDeadLetters
+	}
+	return c
+}
+
+// backoff returns the delay before the retry following attempt.
+func (c Config) backoff(attempt int) time.Duration {
+	delay := c.InitialBackoff
+	for i := 1; i < attempt && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, c.MaxBackoff)
+}
+
+// Endpoint is a URL receiving events of payments to a set of payTo addresses or of a tenant.
+type Endpoint struct {
+	ID  string `json:"id" mapstructure:"id"`
+	URL string `json:"url" mapstructure:"url"`
+	// Secret signing the deliveries, only returned when the endpoint is created
+	Secret string `json:"secret,omitempty" mapstructure:"secret"`
+	// Tenant owning the endpoint, which only receives the tenant's payments
+	TenantID string `json:"tenantId,omitempty" mapstructure:"tenantId"`
+	// Recipient addresses to receive events for, empty receives every payment
+	PayTo []string `json:"payTo,omitempty" mapstructure:"payTo"`
+	// Subscribed event types, empty subscribes to every type
+	Events []string `json:"events,omitempty" mapstructure:"events"`
+}
+
+// validate checks the endpoint and fills in its ID and secret if missing.
+// Hosts that are not public are rejected unless allowPrivate is set.
+func (e *Endpoint) validate(ctx context.Context, allowPrivate bool) error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("invalid webhook url %q", e.URL)
+	}
+	if !allowPrivate {
+		if err := checkHost(ctx, u.Hostname()); err != nil {
+			return err
+		}
+	}
+	for _, event := range e.Events {
+		if !slices.Contains(EventTypes, event) {
+			return fmt.Errorf("unknown webhook event %q", event)
+		}
+	}
+	if e.ID == "" {
+		e.ID = randomID("wh_")
+	}
+	if e.Secret == "" {
+		e.Secret = randomID("whsec_")
+	}
+	return nil
+}
+
+// matches reports whether the endpoint subscribes to event.
+func (e *Endpoint) matches(event *Event) bool {
+	if len(e.Events) > 0 && !slices.Contains(e.Events, event.Type) {
+		return false
+	}
+	if e.TenantID != "" && e.TenantID != event.Data.TenantID {
+		return false
+	}
+	if len(e.PayTo) > 0 && !slices.ContainsFunc(e.PayTo, func(payTo string) bool {
+		return strings.EqualFold(payTo, event.Data.PayTo)
+	}) {
+		return false
+	}
+	return true
+}
+
+// Event is the body of a webhook delivery.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      EventData `json:"data"`
+}
+
+// EventData describes the payment an event is about.
+type EventData struct {
+	// Ledger identifier of the payment
+	PaymentID string `json:"paymentId"`
+	Scheme    string `json:"scheme"`
+	Network   string `json:"network"`
+	Payer     string `json:"payer,omitempty"`
+	PayTo     string `json:"payTo"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	Resource  string `json:"resource"`
+	TxHash    string `json:"txHash,omitempty"`
+	// Reason of a failed settlement
+	Reason    string `json:"reason,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	TenantID  string `json:"tenantId,omitempty"`
+}
+
+// NewEvent creates an event of the given type.
+func NewEvent(eventType string, data EventData) *Event {
+	return &Event{
+		ID:        randomID("evt_"),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// DeadLetter is an event delivery that failed on every attempt.
+type DeadLetter struct {
+	ID         string    `json:"id"`
+	EndpointID string    `json:"endpointId"`
+	TenantID   string    `json:"tenantId,omitempty"`
+	Event      *Event    `json:"event"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"lastError"`
+	FailedAt   time.Time `json:"failedAt"`
+}
+
+func randomID(prefix string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}